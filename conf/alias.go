@@ -0,0 +1,218 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/knadh/koanf"
+	"github.com/snowzach/golib/log"
+)
+
+// DeprecatedTag is the struct tag listing the old keys a field was renamed from.
+// It is a comma separated list of full keys such as deprecated:"server.port".
+const DeprecatedTag = "deprecated"
+
+// deprecationWarned tracks the deprecated keys that have been warned about.
+var deprecationWarned sync.Map
+
+// WithAlias registers an alias from oldKey to newKey. Use it before any parsers that
+// may use oldKey. See RegisterAlias for more information.
+func WithAlias(oldKey string, newKey string) ParserFunc {
+	return func(c *Conf) error {
+		c.RegisterAlias(oldKey, newKey)
+		return nil
+	}
+}
+
+// WithAliases registers the aliases in the deprecated tags of the struct v. Use it before
+// any parsers that may use the old keys. See RegisterAliases for more information.
+func WithAliases(v interface{}, path string, tag string) ParserFunc {
+	return func(c *Conf) error {
+		c.RegisterAliases(v, path, tag)
+		return nil
+	}
+}
+
+// RegisterAlias registers oldKey as a deprecated alias for newKey. Every source loaded
+// afterwards has oldKey moved to newKey and a warning is logged once per key. It's an
+// error if the keys are set to different values by the same source or by two sources
+// other than defaults loaded with WithMap or WithStruct. Otherwise later sources take
+// precedence as usual, so a config file setting oldKey overrides a default for newKey.
+// Environment variables for oldKey also set newKey. See ParseEnvPrefix.
+func (c *Conf) RegisterAlias(oldKey string, newKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.aliases[oldKey] = newKey
+}
+
+// RegisterAliases registers the old keys in the deprecated tags of fields in the struct
+// v as aliases of the field's key. Keys are named using tag (usually "conf") and
+// prefixed with path. See RegisterAlias.
+func (c *Conf) RegisterAliases(v interface{}, path string, tag string) {
+	for oldKey, newKey := range deprecatedAliases(v, path, tag, c.Delimiter) {
+		c.RegisterAlias(oldKey, newKey)
+	}
+}
+
+// deprecatedAliases returns the old key -> new key aliases in the deprecated tags of v.
+func deprecatedAliases(v interface{}, path string, tag string, delimiter string) map[string]string {
+	aliases := make(map[string]string)
+	for _, f := range fields(v, path, tag, delimiter) {
+		for _, oldKey := range strings.Split(f.StructField.Tag.Get(DeprecatedTag), ",") {
+			if oldKey = strings.TrimSpace(oldKey); oldKey != "" {
+				aliases[oldKey] = f.Key
+			}
+		}
+	}
+	return aliases
+}
+
+// applyAliases moves any old keys in k to their new keys. names are the source names
+// of keys which are moved along with them. src is used in errors. It returns the old key
+// of every new key that was set by its old key.
+func applyAliases(k *koanf.Koanf, names map[string]string, aliases map[string]string, src Source) (map[string]string, error) {
+
+	moved := make(map[string]string)
+	for _, oldKey := range sortedKeys(aliases) {
+		newKey := aliases[oldKey]
+		if !k.Exists(oldKey) {
+			continue
+		}
+		value := k.Get(oldKey)
+		if k.Exists(newKey) && !reflect.DeepEqual(k.Get(newKey), value) {
+			if src.Parser != "" {
+				return nil, fmt.Errorf("config keys %s and %s are both set to different values by %s", oldKey, newKey, src)
+			}
+			return nil, fmt.Errorf("config keys %s and %s are both set to different values", oldKey, newKey)
+		}
+		k.Delete(oldKey)
+		if err := k.Set(newKey, value); err != nil {
+			return nil, fmt.Errorf("could not set %s: %w", newKey, err)
+		}
+		if name, ok := names[oldKey]; ok {
+			names[newKey] = name
+		}
+		moved[newKey] = oldKey
+		warnDeprecated(oldKey, newKey)
+	}
+	return moved, nil
+}
+
+// checkAliases returns an error if k, loaded from src, sets an old or new key to a different
+// value than the other key was set to by an earlier source. moved is returned by applyAliases.
+// Defaults can be overridden by either key.
+func (c *Conf) checkAliases(k *koanf.Koanf, aliases map[string]string, moved map[string]string, src Source) error {
+
+	if isDefaultSource(src) {
+		return nil
+	}
+	for _, oldKey := range sortedKeys(aliases) {
+		newKey := aliases[oldKey]
+		if !k.Exists(newKey) || !c.Exists(newKey) || reflect.DeepEqual(c.Get(newKey), k.Get(newKey)) {
+			continue
+		}
+		// Setting the same key again overrides it as usual.
+		c.mu.RLock()
+		_, wasMoved := c.aliased[newKey]
+		c.mu.RUnlock()
+		if _, isMoved := moved[newKey]; isMoved == wasMoved {
+			continue
+		}
+		if prev, ok := c.nonDefaultSource(newKey); ok {
+			return fmt.Errorf("config keys %s and %s are set to different values by %s and %s", oldKey, newKey, prev, src)
+		}
+	}
+	return nil
+}
+
+// applyLoadedAliases moves any old keys in the merged configuration of c to their new
+// keys. It's an error if both keys are set to different values by sources other than
+// defaults or by the same source. Otherwise the key set by the later source wins like
+// it would with registered aliases.
+func (c *Conf) applyLoadedAliases(aliases map[string]string) error {
+
+	k := c.Current().Copy()
+	for _, oldKey := range sortedKeys(aliases) {
+		newKey := aliases[oldKey]
+		if !k.Exists(oldKey) {
+			continue
+		}
+		value := k.Get(oldKey)
+		if k.Exists(newKey) && !reflect.DeepEqual(k.Get(newKey), value) {
+			oldSrc, oldSet := c.nonDefaultSource(oldKey)
+			newSrc, newSet := c.nonDefaultSource(newKey)
+			if oldSet && newSet {
+				if oldSrc == newSrc {
+					return fmt.Errorf("config keys %s and %s are both set to different values by %s", oldKey, newKey, oldSrc)
+				}
+				return fmt.Errorf("config keys %s and %s are set to different values by %s and %s", oldKey, newKey, oldSrc, newSrc)
+			}
+			oldOrder, newOrder := c.keyLoadOrder(oldKey), c.keyLoadOrder(newKey)
+			if oldOrder == newOrder {
+				if src, ok := c.Explain(oldKey); ok {
+					return fmt.Errorf("config keys %s and %s are both set to different values by %s", oldKey, newKey, src)
+				}
+				return fmt.Errorf("config keys %s and %s are both set to different values", oldKey, newKey)
+			}
+			if oldOrder < newOrder {
+				k.Delete(oldKey)
+				warnDeprecated(oldKey, newKey)
+				continue
+			}
+		}
+		k.Delete(oldKey)
+		if err := k.Set(newKey, value); err != nil {
+			return fmt.Errorf("could not set %s: %w", newKey, err)
+		}
+		warnDeprecated(oldKey, newKey)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Koanf = k
+	return nil
+}
+
+// isDefaultSource returns true if src is usually used for defaults, WithMap or WithStruct.
+func isDefaultSource(src Source) bool {
+	return src.Parser == "map" || src.Parser == "struct"
+}
+
+// nonDefaultSource returns the source other than defaults that set key or any key below it.
+func (c *Conf) nonDefaultSource(key string) (Source, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if src, ok := c.provenance[key]; ok && !isDefaultSource(src) {
+		return src, true
+	}
+	prefix := key + c.Delimiter
+	for _, k := range sortedKeys(c.provenance) {
+		if src := c.provenance[k]; strings.HasPrefix(k, prefix) && !isDefaultSource(src) {
+			return src, true
+		}
+	}
+	return Source{}, false
+}
+
+// keyLoadOrder returns the most recent load that set key or any key below it.
+func (c *Conf) keyLoadOrder(key string) uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	order := c.loadOrder[key]
+	prefix := key + c.Delimiter
+	for k, o := range c.loadOrder {
+		if o > order && strings.HasPrefix(k, prefix) {
+			order = o
+		}
+	}
+	return order
+}
+
+// warnDeprecated logs a warning the first time oldKey is used.
+func warnDeprecated(oldKey string, newKey string) {
+	if _, warned := deprecationWarned.LoadOrStore(oldKey, struct{}{}); !warned {
+		log.Warn("config key is deprecated", "key", oldKey, "replacement", newKey)
+	}
+}
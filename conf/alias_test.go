@@ -0,0 +1,90 @@
+package conf
+
+import (
+	"testing"
+)
+
+type aliasConfig struct {
+	HTTP struct {
+		Port int `conf:"port" deprecated:"server.port"`
+	} `conf:"http"`
+}
+
+func TestAliases(t *testing.T) {
+	defaults := WithMap(map[string]interface{}{"http.port": 8080})
+	for name, test := range map[string]struct {
+		parsers []ParserFunc
+		want    int
+		err     bool
+	}{
+		"default":                {parsers: []ParserFunc{defaults}, want: 8080},
+		"old key later":          {parsers: []ParserFunc{defaults, WithBytes([]byte("server:\n  port: 9090\n"), "yaml")}, want: 9090},
+		"new key later":          {parsers: []ParserFunc{WithMap(map[string]interface{}{"server.port": 9090}), WithBytes([]byte("http:\n  port: 7070\n"), "yaml")}, want: 7070},
+		"same value":             {parsers: []ParserFunc{WithBytes([]byte("server:\n  port: 9090\nhttp:\n  port: 9090\n"), "yaml")}, want: 9090},
+		"conflict":               {parsers: []ParserFunc{WithBytes([]byte("server:\n  port: 9090\nhttp:\n  port: 7070\n"), "yaml")}, err: true},
+		"old key only":           {parsers: []ParserFunc{WithMap(map[string]interface{}{"server.port": 9090})}, want: 9090},
+		"default old key":        {parsers: []ParserFunc{WithMap(map[string]interface{}{"server.port": 1}), defaults}, want: 8080},
+		"conflict in map":        {parsers: []ParserFunc{WithMap(map[string]interface{}{"server.port": 1, "http.port": 2})}, err: true},
+		"conflict old key later": {parsers: []ParserFunc{WithBytes([]byte(`{"http":{"port":7070}}`), "json"), WithBytes([]byte("server:\n  port: 9090\n"), "yaml")}, err: true},
+		"conflict new key later": {parsers: []ParserFunc{WithBytes([]byte("server:\n  port: 9090\n"), "yaml"), WithBytes([]byte(`{"http":{"port":7070}}`), "json")}, err: true},
+		"same key later":         {parsers: []ParserFunc{WithBytes([]byte(`{"http":{"port":7070}}`), "json"), WithBytes([]byte("http:\n  port: 9090\n"), "yaml")}, want: 9090},
+	} {
+		for _, register := range []bool{false, true} {
+			c := New()
+			if register {
+				c.RegisterAliases(&aliasConfig{}, "", DefaultTag)
+			}
+			var cfg aliasConfig
+			err := c.Parse(test.parsers...)
+			if err == nil {
+				err = c.Unmarshal(&cfg, UnmarshalConf{})
+			}
+			if test.err {
+				if err == nil {
+					t.Errorf("%s (registered %v): expected an error", name, register)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s (registered %v): %v", name, register, err)
+				continue
+			}
+			if cfg.HTTP.Port != test.want {
+				t.Errorf("%s (registered %v): port = %d, want %d", name, register, cfg.HTTP.Port, test.want)
+			}
+		}
+	}
+}
+
+func TestAliasesStructEnvironment(t *testing.T) {
+	t.Setenv("HTTP_PORT", "7070")
+	for name, test := range map[string]struct {
+		parsers []ParserFunc
+		want    int
+		err     bool
+	}{
+		"env overrides default": {parsers: []ParserFunc{WithMap(map[string]interface{}{"http.port": 8080})}, want: 7070},
+		"env conflicts with old key": {parsers: []ParserFunc{
+			WithMap(map[string]interface{}{"http.port": 8080}),
+			WithBytes([]byte("server:\n  port: 9090\n"), "yaml"),
+		}, err: true},
+	} {
+		c := New()
+		if err := c.Parse(test.parsers...); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var cfg aliasConfig
+		err := c.Unmarshal(&cfg, UnmarshalConf{StructEnvironment: true})
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if cfg.HTTP.Port != test.want {
+			t.Errorf("%s: port = %d, want %d", name, cfg.HTTP.Port, test.want)
+		}
+	}
+}
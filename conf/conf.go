@@ -2,18 +2,11 @@ package conf
 
 import (
 	"fmt"
-	"path/filepath"
-	"strings"
+	"sync"
 
 	"github.com/knadh/koanf"
-	"github.com/knadh/koanf/parsers/json"
-	"github.com/knadh/koanf/parsers/toml"
-	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/confmap"
-	"github.com/knadh/koanf/providers/env"
-	"github.com/knadh/koanf/providers/file"
 	"github.com/knadh/koanf/providers/posflag"
-	"github.com/knadh/koanf/providers/rawbytes"
 	"github.com/knadh/koanf/providers/structs"
 	"github.com/spf13/pflag"
 )
@@ -25,6 +18,34 @@ const DefaultDelimiter = "."
 type Conf struct {
 	*koanf.Koanf
 	Opts
+
+	mu          sync.RWMutex
+	reloadMu    sync.Mutex
+	parsers     []ParserFunc
+	parsing     int // Parse calls in progress, only their files and urls are watched
+	files       []string
+	tenantFiles map[string]string // Tenant file -> tenant, see Watch
+	tenantDirs  []string
+	urls        []*URLSource
+	subscribers map[string][]SubscriberFunc
+	resolvers   map[string]Resolver
+	secrets     map[string]struct{}
+	marked      map[string]struct{} // Keys marked with MarkSecret, kept across Reload
+	resolved    map[string]struct{} // Keys set by a resolver or decryption, never interpolated
+	provenance  map[string]Source
+	loadOrder   map[string]uint64 // The load that last set each key, see applyLoadedAliases
+	loads       uint64
+	aliased     map[string]string // New keys last set by their deprecated old key, see checkAliases
+
+	secretPatterns []string
+	encryptionKey  *[KeySize]byte
+	aliases        map[string]string
+	interpolate    bool
+
+	tenantMu         sync.Mutex
+	tenants          map[string]*tenantLayer
+	tenantCache      map[string]*Conf
+	tenantGeneration uint64
 }
 
 // Opts allows overriding the default tag and delimiters.
@@ -45,20 +66,87 @@ func New() *Conf {
 
 }
 
-// NewWithOpts returns a new Conf instance with custom options.
+// NewWithOpts returns a new Conf instance with custom options. Keys matching
+// DefaultSecretPatterns are secret.
 func NewWithOpts(opts Opts) *Conf {
 	return &Conf{
-		Koanf: koanf.New(opts.Delimiter),
-		Opts:  opts,
+		Koanf:       koanf.New(opts.Delimiter),
+		Opts:        opts,
+		subscribers: make(map[string][]SubscriberFunc),
+		tenantFiles: make(map[string]string),
+		resolvers:   make(map[string]Resolver),
+		secrets:     make(map[string]struct{}),
+		marked:      make(map[string]struct{}),
+		resolved:    make(map[string]struct{}),
+		provenance:  make(map[string]Source),
+		loadOrder:   make(map[string]uint64),
+		aliases:     make(map[string]string),
+		aliased:     make(map[string]string),
+		tenants:     make(map[string]*tenantLayer),
+		tenantCache: make(map[string]*Conf),
+
+		secretPatterns: append([]string(nil), DefaultSecretPatterns...),
 	}
 }
 
+// sibling returns a new empty Conf that shares the options of c. It is
+// used to rebuild the configuration without touching c.
+func (c *Conf) sibling() *Conf {
+	nc := NewWithOpts(c.Opts)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for scheme, r := range c.resolvers {
+		nc.resolvers[scheme] = r
+	}
+	for key := range c.marked {
+		nc.secrets[key] = struct{}{}
+		nc.marked[key] = struct{}{}
+	}
+	nc.secretPatterns = append([]string(nil), c.secretPatterns...)
+	nc.encryptionKey = c.encryptionKey
+	nc.interpolate = c.interpolate
+	for oldKey, newKey := range c.aliases {
+		nc.aliases[oldKey] = newKey
+	}
+	return nc
+}
+
 // ParserFunc is a option function for loading different types of config.
 type ParserFunc func(*Conf) error
 
 // Parse is the all purpose wrapper to parse configuration from a multitude of places.
-// Config sources are provided via ParserFuncs.
+// Config sources are provided via ParserFuncs. The parsers are remembered so
+// the configuration can be rebuilt with Reload, and only files and URLs loaded
+// by them are watched by Watch and PollURLs. Once the parsers have run,
+// any secret references are resolved (see RegisterResolver) and then any
+// placeholders are expanded if enabled with WithInterpolation.
 func (c *Conf) Parse(parsers ...ParserFunc) error {
+	c.mu.Lock()
+	c.parsers = append(c.parsers, parsers...)
+	c.parsing++
+	c.mu.Unlock()
+	err := c.runParsers(parsers)
+	c.mu.Lock()
+	c.parsing--
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	defer c.resetTenantCache()
+	if err := c.ResolveSecrets(); err != nil {
+		return err
+	}
+	c.mu.RLock()
+	interpolate := c.interpolate
+	c.mu.RUnlock()
+	if !interpolate {
+		return nil
+	}
+	return c.Interpolate()
+}
+
+// runParsers runs each parser stopping at the first error.
+func (c *Conf) runParsers(parsers []ParserFunc) error {
 	for _, parser := range parsers {
 		if err := parser(c); err != nil {
 			return err
@@ -108,7 +196,8 @@ func WithStructWithTag(in interface{}, tag string) ParserFunc {
 }
 
 // WithEnvPrefix parses configuration from environment variables given the environment variable prefix..
-// See ParseEnvPrefix for more information. Environment variables can only override existing configuration values.
+// See ParseEnvPrefix for more information. Environment variables can only override existing configuration
+// values, see WithEnvPrefixOpts to create new keys.
 func WithEnvPrefix(prefix string) ParserFunc {
 	return func(c *Conf) error {
 		return c.ParseEnvPrefix(prefix)
@@ -131,43 +220,55 @@ func (c *Conf) ParseMap(config map[string]interface{}) error {
 	if config == nil {
 		return nil
 	}
-	return c.Load(confmap.Provider(config, "."), nil)
+	return c.load(Source{Parser: "map"}, nil, confmap.Provider(config, "."), nil)
 }
 
-// ParseFile loads configuration from a file. It supports yaml, json and toml.
+// ParseFile loads configuration from a file. It supports any registered format
+// including yaml, json, toml, hcl, ini, properties and env. See RegisterFormat.
 // The type is inferred from configFile extension. If configFile is an empty
-// string the file is ignored.
+// string the file is ignored. The file may include other files by listing
+// them under the include key. Includes are loaded before the file itself. Encrypted
+// files or values are decrypted if an encryption key is set. See SetEncryptionKey.
 func (c *Conf) ParseFile(configFile string) error {
 	// If configFile is empty, just skip it.
 	if configFile == "" {
 		return nil
 	}
-	return c.ParseProvider(file.Provider(configFile), filepath.Ext(configFile))
+	return c.parseFile(configFile, nil)
 }
 
-// ParseBytes loads configuration from bytes. It supports yaml, json and toml.
-// The format must be supplied. If buf is empty is it ignored.
+// ParseBytes loads configuration from bytes. It supports any registered format.
+// The format must be supplied. If buf is empty is it ignored. Encrypted bytes or
+// values are decrypted if an encryption key is set. See SetEncryptionKey.
 func (c *Conf) ParseBytes(b []byte, format string) error {
 	// If empty, just skip it.
 	if len(b) == 0 {
 		return nil
 	}
-	return c.ParseProvider(rawbytes.Provider(b), format)
+	config, decrypted, err := c.unmarshalBytes(b, format)
+	if err != nil {
+		return fmt.Errorf("could not parse bytes: %w", err)
+	}
+	if err := c.load(Source{Parser: "bytes", Name: format}, nil, confmap.Provider(config, ""), nil); err != nil {
+		return err
+	}
+	c.markResolved(decrypted...)
+	return nil
 }
 
 // ParseProvider is a helper that takes a koanf provider and format and
 // parses configuration from it..
 func (c *Conf) ParseProvider(p koanf.Provider, format string) error {
+	return c.parseProvider(Source{Parser: "provider", Name: fmt.Sprintf("%T", p)}, p, format)
+}
 
-	switch format {
-	case "yaml", ".yaml", ".yml":
-		return c.Load(p, yaml.Parser())
-	case "json", ".json":
-		return c.Load(p, json.Parser())
-	case "toml", ".toml":
-		return c.Load(p, toml.Parser())
+// parseProvider parses configuration from a provider recording src as the source.
+func (c *Conf) parseProvider(src Source, p koanf.Provider, format string) error {
+	parser, err := formatParser(format)
+	if err != nil {
+		return err
 	}
-	return fmt.Errorf("unknown config format %s", format)
+	return c.load(src, nil, p, parser)
 }
 
 // ParseStruct loads configuration from a struct. If it's nil, it's ignored.
@@ -182,44 +283,28 @@ func (c *Conf) ParseStructWithTag(in interface{}, tag string) error {
 	if in == nil {
 		return nil
 	}
-	return c.Load(structs.Provider(in, tag), nil)
+	return c.load(Source{Parser: "struct", Name: fmt.Sprintf("%T", in)}, nil, structs.Provider(in, tag), nil)
 }
 
 // ParseEnvPrefix parses configuration values from environment variables. It is only
 // useful for overriding values that are already present in the configuration.
 // By default it looks for an environment variable that is all caps with periods
 // replaced by underscores to override. database.user_password would be overridden
-// by an environment variable called DATABASE_USER_PASSWORD.
+// by an environment variable called DATABASE_USER_PASSWORD. See ParseEnvPrefixWithOpts
+// to create new keys.
 func (c *Conf) ParseEnvPrefix(prefix string) error {
-	// All underscores in environment variables to the specified delimeter.
-	envReplacer := strings.NewReplacer("_", c.Delimiter)
-	// Build a map of existing config items with all underscores replaced with dots so `thing.that_value` can
-	// be replaced by environment variable THING_THAT_VALUE instead of it trying to replace `thing.that.value`
-	envLookup := make(map[string]string) //
-	for _, key := range c.Keys() {
-		envLookup[envReplacer.Replace(key)] = key
-	}
-	// Load the environment variables, compare to our lookup of existing values and set override value
-	return c.Load(env.ProviderWithValue(prefix, c.Delimiter, func(key string, value string) (string, interface{}) {
-		// Convert environment variable to lower case and change underscore to dot.
-		key = envReplacer.Replace(strings.ToLower(key))
-		if replacement, found := envLookup[key]; found {
-			// Check the existing type of the variable, and allow modifying.
-			switch c.Get(replacement).(type) {
-			case []interface{}, []string: // If existing value is string slice, split on space.
-				return replacement, strings.Split(value, " ")
-			}
-			return replacement, value
-		}
-		return "", nil // No existing variable, skip it
-	}), nil)
+	return c.ParseEnvPrefixWithOpts(prefix)
 }
 
 // ParseFlagSet will set configuration values from existing command line flags.
 // It uses flag names separated with dots just like the config options.
 func (c *Conf) ParseFlagSet(in *pflag.FlagSet) error {
 
-	if err := c.Load(posflag.Provider(in, ".", c.Koanf), nil); err != nil {
+	flagNames := make(map[string]string)
+	in.VisitAll(func(f *pflag.Flag) {
+		flagNames[f.Name] = "--" + f.Name
+	})
+	if err := c.load(Source{Parser: "flag"}, flagNames, posflag.Provider(in, ".", c.Current()), nil); err != nil {
 		return fmt.Errorf("could not parse command line flags: %w", err)
 	}
 	return nil
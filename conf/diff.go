@@ -0,0 +1,147 @@
+package conf
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+
+	"github.com/snowzach/golib/log"
+)
+
+// ChangeKind is the kind of change to a key.
+type ChangeKind string
+
+// Kinds of change.
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+)
+
+// Change is a single changed key. Old is nil for added keys and New is nil for removed keys.
+type Change struct {
+	Key  string
+	Kind ChangeKind
+	Old  interface{}
+	New  interface{}
+}
+
+// String returns a readable representation of the change.
+func (ch *Change) String() string {
+	switch ch.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s = %v", ch.Key, ch.New)
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s = %v", ch.Key, ch.Old)
+	}
+	return fmt.Sprintf("~ %s = %v -> %v", ch.Key, ch.Old, ch.New)
+}
+
+// Changes is a list of changes sorted by key.
+type Changes []*Change
+
+// Kind returns the changes of the given kind.
+func (changes Changes) Kind(kind ChangeKind) Changes {
+	var out Changes
+	for _, ch := range changes {
+		if ch.Kind == kind {
+			out = append(out, ch)
+		}
+	}
+	return out
+}
+
+// String returns a readable representation of the changes, one per line.
+func (changes Changes) String() string {
+	var b strings.Builder
+	for _, ch := range changes {
+		b.WriteString(ch.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Diff compares the configuration in a to b and returns the keys added, removed and changed
+// in b with their values. Values of keys that are secret in either a or b are redacted.
+func Diff(a, b *Conf) Changes {
+
+	aValues, bValues := a.Current().All(), b.Current().All()
+	keys := make(map[string]struct{}, len(aValues)+len(bValues))
+	for key := range aValues {
+		keys[key] = struct{}{}
+	}
+	for key := range bValues {
+		keys[key] = struct{}{}
+	}
+
+	var changes Changes
+	for _, key := range sortedKeys(keys) {
+		aValue, inA := aValues[key]
+		bValue, inB := bValues[key]
+		ch := &Change{Key: key, Old: aValue, New: bValue}
+		switch {
+		case !inA:
+			ch.Kind = ChangeAdded
+		case !inB:
+			ch.Kind = ChangeRemoved
+		case !reflect.DeepEqual(aValue, bValue):
+			ch.Kind = ChangeChanged
+		default:
+			continue
+		}
+		if a.IsSecret(key) || b.IsSecret(key) {
+			if inA {
+				ch.Old = Redacted
+			}
+			if inB {
+				ch.New = Redacted
+			}
+		}
+		changes = append(changes, ch)
+	}
+	return changes
+}
+
+// LogDiff logs the changes between a and b at info level to logger, or log.Logger if it
+// is nil, and returns them. See Diff.
+func LogDiff(logger *slog.Logger, a, b *Conf) Changes {
+	if logger == nil {
+		logger = log.Logger
+	}
+	changes := Diff(a, b)
+	for _, ch := range changes {
+		args := []interface{}{"key", ch.Key, "change", ch.Kind}
+		if ch.Kind != ChangeAdded {
+			args = append(args, "old", ch.Old)
+		}
+		if ch.Kind != ChangeRemoved {
+			args = append(args, "new", ch.New)
+		}
+		logger.Info("config changed", args...)
+	}
+	return changes
+}
+
+// Snapshot returns a copy of the current configuration and secret keys. It's useful for
+// comparing the configuration before and after Reload with Diff.
+func (c *Conf) Snapshot() *Conf {
+	nc := c.sibling()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	nc.Koanf = c.Koanf.Copy()
+	for key := range c.secrets {
+		nc.secrets[key] = struct{}{}
+	}
+	for key, src := range c.provenance {
+		nc.provenance[key] = src
+	}
+	for key, order := range c.loadOrder {
+		nc.loadOrder[key] = order
+	}
+	nc.loads = c.loads
+	for newKey, oldKey := range c.aliased {
+		nc.aliased[newKey] = oldKey
+	}
+	return nc
+}
@@ -0,0 +1,91 @@
+package conf
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/creasty/defaults"
+	"github.com/knadh/koanf/maps"
+)
+
+// WriteSample writes a sample configuration file for the struct in v in format
+// (yaml, json or toml). Every key is included using the values in v with the default
+// tag applied to any that are not set. Keys are named using tag (usually "conf") and
+// nested under path.
+func WriteSample(w io.Writer, v interface{}, path string, tag string, format string) error {
+
+	parser, err := formatParser(format)
+	if err != nil {
+		return err
+	}
+
+	sample, err := defaultedCopy(v)
+	if err != nil {
+		return err
+	}
+
+	flat := make(map[string]interface{})
+	for _, f := range Fields(sample, path, tag) {
+		flat[f.Key] = plainValue(f.Value)
+	}
+
+	b, err := parser.Marshal(maps.Unflatten(flat, DefaultDelimiter))
+	if err != nil {
+		return fmt.Errorf("could not marshal sample config: %w", err)
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// WriteMarkdown writes a Markdown table documenting every key in the struct type of v
+// including its type, default, environment variable and description from the help tag.
+// Keys are named using tag (usually "conf") and nested under path.
+func WriteMarkdown(w io.Writer, v interface{}, path string, tag string) error {
+
+	if _, err := fmt.Fprint(w, "| Key | Type | Default | Environment | Description |\n|---|---|---|---|---|\n"); err != nil {
+		return err
+	}
+	for _, f := range Fields(v, path, tag) {
+		if _, err := fmt.Fprintf(w, "| `%s` | `%s` | %s | `%s` | %s |\n",
+			f.Key,
+			f.Type,
+			markdownCode(f.Default),
+			f.EnvVar,
+			markdownEscape(f.Help),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultedCopy returns a pointer to a copy of the struct in v with defaults applied
+// to any fields that are not set.
+func defaultedCopy(v interface{}) (interface{}, error) {
+	value := indirectValue(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected struct, got %T", v)
+	}
+	copied := reflect.New(value.Type())
+	copied.Elem().Set(value)
+	out := copied.Interface()
+	if err := defaults.Set(out); err != nil {
+		return nil, fmt.Errorf("could not set struct defaults: %w", err)
+	}
+	return out, nil
+}
+
+// markdownCode wraps a non-empty value in backticks.
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + markdownEscape(strings.ReplaceAll(s, "`", "'")) + "`"
+}
+
+// markdownEscape escapes characters that would break a table cell.
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,79 @@
+package conf
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/knadh/koanf/maps"
+)
+
+// MarshalFormat marshals the merged configuration in any registered format. Secret values
+// are included as is, use Dump or MarshalRedacted to redact them.
+func (c *Conf) MarshalFormat(format string) ([]byte, error) {
+	return c.marshal(format, false)
+}
+
+// MarshalRedacted marshals the merged configuration in any registered format with any
+// secret values redacted. See MarkSecret, MarkSecretPattern and MarkSecretFields.
+func (c *Conf) MarshalRedacted(format string) ([]byte, error) {
+	return c.marshal(format, true)
+}
+
+// Dump writes the merged configuration to w in any registered format with any secret
+// values redacted. It is intended for logging the effective configuration.
+func (c *Conf) Dump(w io.Writer, format string) error {
+	b, err := c.MarshalRedacted(format)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// DumpHandler returns an http.Handler that serves the merged configuration in format
+// with any secret values redacted. It is intended for a debug endpoint.
+func (c *Conf) DumpHandler(format string) http.Handler {
+	contentType := "text/plain; charset=utf-8"
+	switch normalizeFormat(format) {
+	case "json":
+		contentType = "application/json"
+	case "yaml", "yml":
+		contentType = "application/yaml"
+	case "toml":
+		contentType = "application/toml"
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := c.MarshalRedacted(format)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(b)
+	})
+}
+
+// marshal marshals the configuration in format optionally redacting secrets.
+func (c *Conf) marshal(format string, redact bool) ([]byte, error) {
+
+	parser, err := formatParser(format)
+	if err != nil {
+		return nil, err
+	}
+
+	flat := c.Current().All()
+	if redact {
+		for key := range flat {
+			if c.IsSecret(key) {
+				flat[key] = Redacted
+			}
+		}
+	}
+
+	b, err := parser.Marshal(maps.Unflatten(flat, c.Delimiter))
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal config: %w", err)
+	}
+	return b, nil
+}
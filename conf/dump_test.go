@@ -0,0 +1,51 @@
+package conf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/knadh/koanf/parsers/json"
+)
+
+func TestMarshal(t *testing.T) {
+	c := New()
+	if err := c.Parse(WithMap(map[string]interface{}{"db.user": "app", "db.secret": "hunter2"})); err != nil {
+		t.Fatal(err)
+	}
+	c.MarkSecret("db.secret")
+
+	// The embedded koanf Marshal still takes a parser.
+	b, err := c.Marshal(json.Parser())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "hunter2") {
+		t.Errorf("Marshal = %s, want the secret value", b)
+	}
+
+	if b, err = c.MarshalFormat("json"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "hunter2") {
+		t.Errorf("MarshalFormat = %s, want the secret value", b)
+	}
+
+	if b, err = c.MarshalRedacted("json"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "hunter2") || !strings.Contains(string(b), Redacted) {
+		t.Errorf("MarshalRedacted = %s, want the secret redacted", b)
+	}
+}
+
+func TestDefaultSecretPatterns(t *testing.T) {
+	c := New()
+	if err := c.Parse(WithMap(map[string]interface{}{"db.user": "app", "db.Password": "hunter2", "api.token": "abc"})); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]bool{"db.user": false, "db.Password": true, "api.token": true} {
+		if got := c.IsSecret(key); got != want {
+			t.Errorf("IsSecret(%s) = %v, want %v", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package conf
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/knadh/koanf/maps"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+const (
+	// EncryptedPrefix and EncryptedSuffix surround encrypted values and files.
+	EncryptedPrefix = "ENC[secretbox,"
+	EncryptedSuffix = "]"
+
+	// KeySize is the size of an encryption key.
+	KeySize = 32
+
+	nonceSize = 24
+)
+
+// ErrNoEncryptionKey is returned when encrypted configuration is found without a key.
+var ErrNoEncryptionKey = errors.New("encrypted config found but no encryption key set")
+
+// WithEncryptionKey sets the key used to decrypt configuration.
+// See SetEncryptionKey for more information.
+func WithEncryptionKey(key *[KeySize]byte) ParserFunc {
+	return func(c *Conf) error {
+		c.SetEncryptionKey(key)
+		return nil
+	}
+}
+
+// WithEncryptionKeyFile reads the base64 encoded key used to decrypt configuration from a file.
+// See SetEncryptionKey for more information.
+func WithEncryptionKeyFile(keyFile string) ParserFunc {
+	return func(c *Conf) error {
+		b, err := os.ReadFile(keyFile)
+		if err != nil {
+			return fmt.Errorf("could not read encryption key: %w", err)
+		}
+		key, err := ParseKey(string(b))
+		if err != nil {
+			return err
+		}
+		c.SetEncryptionKey(key)
+		return nil
+	}
+}
+
+// WithEncryptionKeyEnv reads the base64 encoded key used to decrypt configuration from
+// an environment variable. See SetEncryptionKey for more information.
+func WithEncryptionKeyEnv(envVar string) ParserFunc {
+	return func(c *Conf) error {
+		value, ok := os.LookupEnv(envVar)
+		if !ok {
+			return fmt.Errorf("encryption key environment variable %s is not set", envVar)
+		}
+		key, err := ParseKey(value)
+		if err != nil {
+			return err
+		}
+		c.SetEncryptionKey(key)
+		return nil
+	}
+}
+
+// SetEncryptionKey sets the key used to decrypt configuration. Once set, files and bytes
+// that are entirely encrypted, or contain encrypted string values, are decrypted when
+// parsed. Decrypted values, and every value in an encrypted file, are marked secret. See Encrypt.
+func (c *Conf) SetEncryptionKey(key *[KeySize]byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.encryptionKey = key
+}
+
+// GenerateKey returns a new random encryption key.
+func GenerateKey() (*[KeySize]byte, error) {
+	key := new([KeySize]byte)
+	if _, err := io.ReadFull(rand.Reader, key[:]); err != nil {
+		return nil, fmt.Errorf("could not generate key: %w", err)
+	}
+	return key, nil
+}
+
+// EncodeKey returns the base64 encoding of a key as read by ParseKey.
+func EncodeKey(key *[KeySize]byte) string {
+	return base64.StdEncoding.EncodeToString(key[:])
+}
+
+// ParseKey parses a base64 encoded key. Surrounding whitespace is ignored.
+func ParseKey(s string) (*[KeySize]byte, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode encryption key: %w", err)
+	}
+	if len(b) != KeySize {
+		return nil, fmt.Errorf("encryption key must be %d bytes, got %d", KeySize, len(b))
+	}
+	key := new([KeySize]byte)
+	copy(key[:], b)
+	return key, nil
+}
+
+// Encrypt encrypts plaintext with key and returns it as ENC[secretbox,...]. The result
+// can be used as a string value in a config file or as the entire contents of one.
+func Encrypt(key *[KeySize]byte, plaintext []byte) (string, error) {
+	var nonce [nonceSize]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return "", fmt.Errorf("could not generate nonce: %w", err)
+	}
+	box := secretbox.Seal(nonce[:], plaintext, &nonce, key)
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(box) + EncryptedSuffix, nil
+}
+
+// Decrypt decrypts a value returned from Encrypt.
+func Decrypt(key *[KeySize]byte, value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if !IsEncrypted(value) {
+		return nil, fmt.Errorf("value is not encrypted")
+	}
+	box, err := base64.StdEncoding.DecodeString(value[len(EncryptedPrefix) : len(value)-len(EncryptedSuffix)])
+	if err != nil {
+		return nil, fmt.Errorf("could not decode encrypted value: %w", err)
+	}
+	if len(box) < nonceSize+secretbox.Overhead {
+		return nil, fmt.Errorf("encrypted value is too short")
+	}
+	var nonce [nonceSize]byte
+	copy(nonce[:], box[:nonceSize])
+	plaintext, ok := secretbox.Open(nil, box[nonceSize:], &nonce, key)
+	if !ok {
+		return nil, fmt.Errorf("could not decrypt value: wrong key or corrupt value")
+	}
+	return plaintext, nil
+}
+
+// IsEncrypted returns true if the value is an encrypted value.
+func IsEncrypted(value string) bool {
+	value = strings.TrimSpace(value)
+	return strings.HasPrefix(value, EncryptedPrefix) && strings.HasSuffix(value, EncryptedSuffix)
+}
+
+// unmarshalBytes parses config bytes in format decrypting the whole thing or any
+// encrypted values as needed. It returns the config and any keys that were decrypted.
+func (c *Conf) unmarshalBytes(b []byte, format string) (map[string]interface{}, []string, error) {
+
+	parser, err := formatParser(format)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.RLock()
+	key := c.encryptionKey
+	c.mu.RUnlock()
+
+	// Decrypt the whole thing if it's encrypted.
+	whole := IsEncrypted(string(b))
+	if whole {
+		if key == nil {
+			return nil, nil, ErrNoEncryptionKey
+		}
+		if b, err = Decrypt(key, string(b)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	config, err := parser.Unmarshal(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	maps.IntfaceKeysToStrings(config)
+
+	var decrypted []string
+	if err := decryptValues(key, config, "", c.Delimiter, &decrypted); err != nil {
+		return nil, nil, err
+	}
+	// Every key came from decryption.
+	if whole {
+		flat, _ := maps.Flatten(config, nil, c.Delimiter)
+		decrypted = decrypted[:0]
+		for key := range flat {
+			decrypted = append(decrypted, key)
+		}
+	}
+	return config, decrypted, nil
+}
+
+// decryptValues decrypts any encrypted string values in v in place. A decrypted slice
+// element records the slice key as koanf does not address slice elements.
+func decryptValues(key *[KeySize]byte, v interface{}, prefix string, delimiter string, decrypted *[]string) error {
+
+	decrypt := func(path string, secretKey string, value interface{}) (interface{}, error) {
+		s, ok := value.(string)
+		if !ok || !IsEncrypted(s) {
+			return value, decryptValues(key, value, path, delimiter, decrypted)
+		}
+		if key == nil {
+			return nil, fmt.Errorf("%s: %w", path, ErrNoEncryptionKey)
+		}
+		plaintext, err := Decrypt(key, s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		*decrypted = append(*decrypted, secretKey)
+		return string(plaintext), nil
+	}
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, elem := range value {
+			path := joinKeyDelimiter(prefix, k, delimiter)
+			out, err := decrypt(path, path, elem)
+			if err != nil {
+				return err
+			}
+			value[k] = out
+		}
+	case []interface{}:
+		for i, elem := range value {
+			out, err := decrypt(joinKeyDelimiter(prefix, strconv.Itoa(i), delimiter), prefix, elem)
+			if err != nil {
+				return err
+			}
+			value[i] = out
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedFile(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := Encrypt(key, []byte("db:\n  pass: hunter2\n  hosts: [a, b]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "secrets.yaml")
+	if err := os.WriteFile(file, []byte(encrypted), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New()
+	if err := c.Parse(WithFile(file)); err == nil {
+		t.Fatal("expected an error without a key")
+	}
+
+	c = New()
+	c.SetEncryptionKey(key)
+	if err := c.Parse(WithFile(file)); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("db.pass"); got != "hunter2" {
+		t.Fatalf("db.pass = %q, want hunter2", got)
+	}
+	for _, key := range []string{"db.pass", "db.hosts"} {
+		if !c.IsSecret(key) {
+			t.Errorf("%s should be secret", key)
+		}
+	}
+	if strings.Contains(c.Sprint(), "hunter2") || strings.Contains(c.SprintProvenance(), "hunter2") {
+		t.Error("decrypted value was printed")
+	}
+}
+
+func TestEncryptedValue(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := Encrypt(key, []byte("hunter2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := New()
+	c.SetEncryptionKey(key)
+	if err := c.Parse(WithBytes([]byte(`{"db": {"pass": "`+encrypted+`", "user": "admin"}}`), "json")); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("db.pass"); got != "hunter2" {
+		t.Fatalf("db.pass = %q, want hunter2", got)
+	}
+	if !c.IsSecret("db.pass") || c.IsSecret("db.user") {
+		t.Error("only db.pass should be secret")
+	}
+}
@@ -0,0 +1,231 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/knadh/koanf/providers/confmap"
+)
+
+// EnvConf configures how environment variables are parsed. See ParseEnvPrefixWithOpts.
+type EnvConf struct {
+	// CreateKeys allows environment variables to create keys that do not already exist.
+	CreateKeys bool
+	// SliceSeparator splits values overriding existing slices. The default is a space.
+	SliceSeparator string
+	// JSONValues parses values beginning with { or [ as JSON.
+	JSONValues bool
+}
+
+// EnvOption is used to override EnvConf options.
+type EnvOption func(ec *EnvConf)
+
+// WithEnvCreateKeys allows environment variables to create new keys.
+func WithEnvCreateKeys(b bool) EnvOption {
+	return func(ec *EnvConf) {
+		ec.CreateKeys = b
+	}
+}
+
+// WithEnvSliceSeparator sets the separator used to split values overriding existing slices.
+func WithEnvSliceSeparator(sep string) EnvOption {
+	return func(ec *EnvConf) {
+		ec.SliceSeparator = sep
+	}
+}
+
+// WithEnvJSONValues parses values beginning with { or [ as JSON.
+func WithEnvJSONValues(b bool) EnvOption {
+	return func(ec *EnvConf) {
+		ec.JSONValues = b
+	}
+}
+
+// WithEnvPrefixOpts parses configuration from environment variables given the environment
+// variable prefix and options. See ParseEnvPrefixWithOpts for more information.
+func WithEnvPrefixOpts(prefix string, opts ...EnvOption) ParserFunc {
+	return func(c *Conf) error {
+		return c.ParseEnvPrefixWithOpts(prefix, opts...)
+	}
+}
+
+// ParseEnvPrefixWithOpts parses configuration values from environment variables beginning
+// with prefix. Environment variables matching an existing key override it as described in
+// ParseEnvPrefix and values for existing slices are split on the SliceSeparator.
+//
+// With CreateKeys, any other environment variable creates a new key. Underscores nest
+// keys except where they match the name of an existing key. Numeric parts index slices so
+// SERVERS_0_HOST sets the host of the first entry in servers, creating the slice if needed,
+// and LABELS_TEAM=core adds team to the labels map. With JSONValues, values beginning
+// with { or [ are parsed as JSON so complex values can be set with a single variable.
+func (c *Conf) ParseEnvPrefixWithOpts(prefix string, opts ...EnvOption) error {
+
+	ec := &EnvConf{
+		SliceSeparator: " ",
+	}
+	for _, opt := range opts {
+		opt(ec)
+	}
+
+	// All underscores in environment variables to the specified delimeter.
+	envReplacer := strings.NewReplacer("_", c.Delimiter)
+	// Build a map of existing config items with all underscores replaced with dots so `thing.that_value` can
+	// be replaced by environment variable THING_THAT_VALUE instead of it trying to replace `thing.that.value`
+	envLookup := make(map[string]string)
+	for _, key := range c.Keys() {
+		envLookup[envReplacer.Replace(key)] = key
+	}
+	// Deprecated keys override their replacements.
+	aliases := make(map[string]string)
+	c.mu.RLock()
+	for oldKey, newKey := range c.aliases {
+		aliases[oldKey] = newKey
+	}
+	c.mu.RUnlock()
+	for oldKey, newKey := range aliases {
+		if c.Exists(newKey) {
+			envLookup[envReplacer.Replace(oldKey)] = oldKey
+		}
+	}
+
+	var envVars []string
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, prefix) {
+			envVars = append(envVars, kv)
+		}
+	}
+	sort.Strings(envVars)
+
+	// Keep track of the environment variable used for each key.
+	envNames := make(map[string]string)
+	config := make(map[string]interface{})
+	var tree interface{} = c.Raw()
+	for _, kv := range envVars {
+		envName, value, _ := strings.Cut(kv, "=")
+
+		var v interface{} = value
+		if trimmed := strings.TrimSpace(value); ec.JSONValues && (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) {
+			if err := json.Unmarshal([]byte(trimmed), &v); err != nil {
+				return fmt.Errorf("could not parse json in environment variable %s: %w", envName, err)
+			}
+		}
+
+		// Convert environment variable to lower case and change underscore to dot.
+		if replacement, found := envLookup[envReplacer.Replace(strings.ToLower(envName))]; found {
+			// Check the existing type of the variable, and allow modifying.
+			existing := replacement
+			if newKey, ok := aliases[replacement]; ok {
+				existing = newKey
+			}
+			if _, isString := v.(string); isString {
+				switch c.Get(existing).(type) {
+				case []interface{}, []string: // If existing value is a slice, split on the separator.
+					v = strings.Split(value, ec.SliceSeparator)
+				}
+			}
+			config[replacement] = v
+			envNames[replacement] = envName
+			continue
+		}
+		if !ec.CreateKeys {
+			continue // No existing variable, skip it
+		}
+
+		path := envPath(tree, strings.Split(strings.ToLower(envName), "_"))
+		var err error
+		if tree, err = setEnvPath(tree, path, v); err != nil {
+			return fmt.Errorf("could not set environment variable %s: %w", envName, err)
+		}
+		// Slices are replaced rather than merged so set the whole slice.
+		root := envRoot(tree, path)
+		key := strings.Join(root, c.Delimiter)
+		config[key] = getEnvPath(tree, root)
+		envNames[key] = envName
+	}
+
+	return c.load(Source{Parser: "env"}, envNames, confmap.Provider(config, c.Delimiter), nil)
+}
+
+// envPath resolves the parts of an environment variable name into key parts using the
+// existing keys in node. Parts are joined with underscores where that matches an existing
+// key, otherwise each part is nested.
+func envPath(node interface{}, parts []string) []string {
+	if len(parts) == 0 {
+		return nil
+	}
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for i := len(parts); i > 0; i-- {
+			name := strings.Join(parts[:i], "_")
+			if child, ok := n[name]; ok {
+				return append([]string{name}, envPath(child, parts[i:])...)
+			}
+		}
+	case []interface{}:
+		if index, err := strconv.Atoi(parts[0]); err == nil && index >= 0 && index < len(n) {
+			return append([]string{parts[0]}, envPath(n[index], parts[1:])...)
+		}
+	}
+	return parts
+}
+
+// setEnvPath sets value at path in node and returns the updated node. Missing maps and
+// slices are created, slices when the path part is numeric.
+func setEnvPath(node interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	index, err := strconv.Atoi(path[0])
+	isIndex := err == nil && index >= 0
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, err := setEnvPath(n[path[0]], path[1:], value)
+		n[path[0]] = child
+		return n, err
+	case []interface{}:
+		if !isIndex {
+			return nil, fmt.Errorf("invalid slice index %s", path[0])
+		}
+		for len(n) <= index {
+			n = append(n, nil)
+		}
+		child, err := setEnvPath(n[index], path[1:], value)
+		n[index] = child
+		return n, err
+	}
+	if isIndex {
+		return setEnvPath(make([]interface{}, 0, index+1), path, value)
+	}
+	return setEnvPath(make(map[string]interface{}), path, value)
+}
+
+// getEnvPath returns the value at path in node.
+func getEnvPath(node interface{}, path []string) interface{} {
+	for _, part := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			node = n[part]
+		case []interface{}:
+			index, _ := strconv.Atoi(part)
+			node = n[index]
+		}
+	}
+	return node
+}
+
+// envRoot returns the shortest prefix of path that is a slice in node or the whole path.
+func envRoot(node interface{}, path []string) []string {
+	for i, part := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			node = n[part]
+		case []interface{}:
+			return path[:i]
+		}
+	}
+	return path
+}
@@ -0,0 +1,59 @@
+package conf
+
+import (
+	"reflect"
+	"testing"
+)
+
+// The prefix selects environment variables but is part of the key they match.
+func TestParseEnvPrefix(t *testing.T) {
+	t.Setenv("ENVTEST_SERVER_PORT", "9090")
+	t.Setenv("ENVTEST_SERVER_HOSTS", "a b")
+	t.Setenv("ENVTEST_SERVER_MISSING", "x")
+
+	c := New()
+	if err := c.Parse(
+		WithMap(map[string]interface{}{"envtest.server.port": 8080, "envtest.server.hosts": []string{"z"}}),
+		WithEnvPrefix("ENVTEST_"),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if c.Int("envtest.server.port") != 9090 {
+		t.Errorf("envtest.server.port = %d, want 9090", c.Int("envtest.server.port"))
+	}
+	if hosts := c.Strings("envtest.server.hosts"); !reflect.DeepEqual(hosts, []string{"a", "b"}) {
+		t.Errorf("envtest.server.hosts = %v, want [a b]", hosts)
+	}
+	if c.Exists("envtest.server.missing") {
+		t.Error("envtest.server.missing should not be created")
+	}
+	if src, _ := c.Explain("envtest.server.port"); src.Name != "ENVTEST_SERVER_PORT" {
+		t.Errorf("envtest.server.port source = %s", src)
+	}
+}
+
+func TestParseEnvPrefixCreateKeys(t *testing.T) {
+	t.Setenv("ENVTEST_SERVERS_0_HOST", "a")
+	t.Setenv("ENVTEST_SERVERS_1_HOST", "b")
+	t.Setenv("ENVTEST_LABELS_TEAM", "core")
+	t.Setenv("ENVTEST_LOG_LEVEL", "debug")
+	t.Setenv("ENVTEST_TLS", `{"enabled": true}`)
+
+	c := New()
+	if err := c.Parse(
+		WithMap(map[string]interface{}{"envtest.log_level": "info"}),
+		WithEnvPrefixOpts("ENVTEST_", WithEnvCreateKeys(true), WithEnvJSONValues(true)),
+	); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]interface{}{
+		"envtest.servers":     []interface{}{map[string]interface{}{"host": "a"}, map[string]interface{}{"host": "b"}},
+		"envtest.labels.team": "core",
+		"envtest.log_level":   "debug",
+		"envtest.tls.enabled": true,
+	} {
+		if got := c.Get(key); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s = %#v, want %#v", key, got, want)
+		}
+	}
+}
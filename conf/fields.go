@@ -0,0 +1,215 @@
+package conf
+
+import (
+	"crypto/x509"
+	"encoding"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/knadh/koanf/maps"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// HelpTag is the struct tag used to describe a configuration field.
+	HelpTag = "help"
+	// DefaultsTag is the struct tag used by github.com/creasty/defaults for defaults.
+	DefaultsTag = "default"
+)
+
+// Field describes a single configuration value found in a struct.
+type Field struct {
+	// Key is the full configuration key.
+	Key string
+	// EnvVar is the environment variable that overrides the key. See ParseEnvPrefix.
+	EnvVar string
+	// Type is the Go type of the field.
+	Type reflect.Type
+	// Default is the value of the default tag.
+	Default string
+	// Help is the value of the help tag.
+	Help string
+	// Value is the value of the field in the struct.
+	Value reflect.Value
+	// StructField is the struct field itself.
+	StructField reflect.StructField
+}
+
+// Fields walks the struct (or pointer to struct) in v and returns every configuration
+// value in it. Keys are named using tag (usually "conf") and prefixed with path. Nested
+// structs are walked except for types that decode from a single value like time.Time or
+// decimal.Decimal. Interface, func and chan fields are skipped.
+func Fields(v interface{}, path string, tag string) []Field {
+	return fields(v, path, tag, DefaultDelimiter)
+}
+
+// fields walks v using the given delimiter to build keys.
+func fields(v interface{}, path string, tag string, delimiter string) []Field {
+	var out []Field
+	walkFields(reflect.ValueOf(v), path, tag, delimiter, &out)
+	return out
+}
+
+// walkFields appends every field in the struct value v to out.
+func walkFields(v reflect.Value, key string, tag string, delimiter string, out *[]Field) {
+
+	v = indirectValue(v)
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		switch field.Type.Kind() {
+		case reflect.Interface, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+			continue
+		}
+		name, squash, skip := fieldKey(field, tag)
+		if skip {
+			continue
+		}
+		fieldKey := key
+		if !squash {
+			fieldKey = joinKeyDelimiter(key, name, delimiter)
+		}
+
+		value := v.Field(i)
+		if !isLeafType(field.Type) {
+			walkFields(value, fieldKey, tag, delimiter, out)
+			continue
+		}
+
+		*out = append(*out, Field{
+			Key:         fieldKey,
+			EnvVar:      EnvVarName(fieldKey, delimiter),
+			Type:        field.Type,
+			Default:     field.Tag.Get(DefaultsTag),
+			Help:        field.Tag.Get(HelpTag),
+			Value:       value,
+			StructField: field,
+		})
+	}
+}
+
+// EnvVarName returns the environment variable name used by ParseEnvPrefix to override key.
+func EnvVarName(key string, delimiter string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, delimiter, "_"))
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// isLeafType returns true if the type is decoded from a single config value rather
+// than walked as a nested structure.
+func isLeafType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+	switch t {
+	case reflect.TypeOf(time.Time{}), reflect.TypeOf(decimal.Decimal{}), reflect.TypeOf(net.IPNet{}), reflect.TypeOf(url.URL{}),
+		reflect.TypeOf(regexp.Regexp{}), reflect.TypeOf(time.Location{}), reflect.TypeOf(x509.CertPool{}):
+		return true
+	}
+	return reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// indirectValue follows pointers and interfaces. Nil pointers return the zero value of their type.
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if v.Kind() == reflect.Interface {
+				return reflect.Value{}
+			}
+			return reflect.New(v.Type().Elem()).Elem()
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// plainValue converts a field value into the plain value it would be configured with
+// such as strings for durations, log levels and anything implementing encoding.TextMarshaler.
+func plainValue(v reflect.Value) interface{} {
+
+	v = indirectValue(v)
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch v.Type() {
+	case reflect.TypeOf(time.Duration(0)):
+		return time.Duration(v.Int()).String()
+	case reflect.TypeOf(slog.LevelInfo):
+		return strings.ToLower(slog.Level(v.Int()).String())
+	case reflect.TypeOf(fs.FileMode(0)):
+		return fmt.Sprintf("%#o", v.Uint())
+	}
+
+	// Use text or string representations where possible.
+	iface := v.Interface()
+	if v.CanAddr() {
+		iface = v.Addr().Interface()
+	}
+	if m, ok := iface.(encoding.TextMarshaler); ok {
+		if b, err := m.MarshalText(); err == nil {
+			return string(b)
+		}
+	}
+	if s, ok := iface.(fmt.Stringer); ok && v.Kind() == reflect.Struct {
+		return s.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	case reflect.Slice, reflect.Array:
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = plainValue(v.Index(i))
+		}
+		return out
+	case reflect.Map:
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[fmt.Sprint(iter.Key().Interface())] = plainValue(iter.Value())
+		}
+		return out
+	case reflect.Struct:
+		out := make(map[string]interface{})
+		for _, f := range Fields(v.Interface(), "", DefaultTag) {
+			out[f.Key] = plainValue(f.Value)
+		}
+		return maps.Unflatten(out, DefaultDelimiter)
+	}
+	return v.Interface()
+}
+
+// joinKeyDelimiter joins a key prefix and name with the delimiter.
+func joinKeyDelimiter(prefix string, name string, delimiter string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + delimiter + name
+}
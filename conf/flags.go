@@ -0,0 +1,128 @@
+package conf
+
+import (
+	"net"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// RegisterFlags registers a pflag for every configuration value in the struct v so that
+// ParseFlagSet picks them up. Flags are named with the full dotted config key (using tag,
+// usually "conf" and nested under path), use the help tag for usage and default to the value
+// in v or the default tag if it's not set. Flags that are already defined are left alone.
+// Values that can't be expressed as a flag, like slices of structs, are skipped.
+func RegisterFlags(fs *pflag.FlagSet, v interface{}, path string, tag string) error {
+
+	defaulted, err := defaultedCopy(v)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range Fields(defaulted, path, tag) {
+		if fs.Lookup(f.Key) != nil {
+			continue
+		}
+		registerFlag(fs, f)
+	}
+
+	return nil
+}
+
+// registerFlag registers a typed flag for the field.
+func registerFlag(fs *pflag.FlagSet, f Field) {
+
+	name, usage := f.Key, f.Help
+	value := indirectValue(f.Value)
+	t := value.Type()
+
+	// Special types first
+	switch t {
+	case reflect.TypeOf(time.Duration(0)):
+		fs.Duration(name, time.Duration(value.Int()), usage)
+		return
+	case reflect.TypeOf(net.IP{}):
+		fs.IP(name, value.Interface().(net.IP), usage)
+		return
+	case reflect.TypeOf(net.IPNet{}):
+		fs.IPNet(name, value.Interface().(net.IPNet), usage)
+		return
+	case reflect.TypeOf([]time.Duration{}):
+		fs.DurationSlice(name, value.Interface().([]time.Duration), usage)
+		return
+	case reflect.TypeOf([]int{}):
+		fs.IntSlice(name, value.Interface().([]int), usage)
+		return
+	case reflect.TypeOf([]bool{}):
+		fs.BoolSlice(name, value.Interface().([]bool), usage)
+		return
+	case reflect.TypeOf([]float64{}):
+		fs.Float64Slice(name, value.Interface().([]float64), usage)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		fs.Bool(name, value.Bool(), usage)
+		return
+	case reflect.Int:
+		if !isTextType(t) {
+			fs.Int(name, int(value.Int()), usage)
+			return
+		}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !isTextType(t) {
+			fs.Int64(name, value.Int(), usage)
+			return
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		// File modes are octal strings.
+		if !isTextType(t) && t != reflect.TypeOf(os.FileMode(0)) {
+			fs.Uint64(name, value.Uint(), usage)
+			return
+		}
+	case reflect.Float32, reflect.Float64:
+		fs.Float64(name, value.Float(), usage)
+		return
+	case reflect.String:
+		fs.String(name, value.String(), usage)
+		return
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String {
+			fs.StringSlice(name, stringSlice(value), usage)
+		}
+		return
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.String {
+			m := make(map[string]string, value.Len())
+			iter := value.MapRange()
+			for iter.Next() {
+				m[iter.Key().String()] = iter.Value().String()
+			}
+			fs.StringToString(name, m, usage)
+		}
+		return
+	}
+
+	// Anything else that has a single string representation (log levels, times, decimals,
+	// byte sizes, file modes).
+	if s, ok := plainValue(value).(string); ok {
+		fs.String(name, s, usage)
+	}
+}
+
+// isTextType returns true for integer types that are configured with text like slog.Level.
+func isTextType(t reflect.Type) bool {
+	return t.Implements(textUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// stringSlice converts a slice of a string kind to []string.
+func stringSlice(v reflect.Value) []string {
+	out := make([]string, v.Len())
+	for i := range out {
+		out[i] = v.Index(i).String()
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package conf
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func TestRegisterFlags(t *testing.T) {
+	type config struct {
+		Max     ByteSize      `conf:"max" help:"Max size"`
+		Mode    os.FileMode   `conf:"mode" help:"File mode"`
+		Port    uint16        `conf:"port"`
+		Timeout time.Duration `conf:"timeout"`
+		Name    string        `conf:"name"`
+	}
+	defaults := config{Max: 64 * MiB, Mode: 0644, Port: 8080, Timeout: time.Second, Name: "test"}
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	if err := RegisterFlags(fs, &defaults, "server", DefaultTag); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"server.max":     "64MiB",
+		"server.mode":    "0644",
+		"server.port":    "8080",
+		"server.timeout": "1s",
+		"server.name":    "test",
+	} {
+		f := fs.Lookup(name)
+		if f == nil {
+			t.Fatalf("flag %s not registered", name)
+		}
+		if f.DefValue != want {
+			t.Errorf("flag %s default = %q, want %q", name, f.DefValue, want)
+		}
+	}
+
+	if err := fs.Parse([]string{"--server.max=512MiB", "--server.mode=0600", "--server.port=9090"}); err != nil {
+		t.Fatal(err)
+	}
+	c := New()
+	if err := c.ParseFlagSet(fs); err != nil {
+		t.Fatal(err)
+	}
+	var got config
+	if err := c.Unmarshal(&got, UnmarshalConf{Path: "server"}); err != nil {
+		t.Fatal(err)
+	}
+	want := config{Max: 512 * MiB, Mode: 0600, Port: 9090, Timeout: time.Second, Name: "test"}
+	if got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
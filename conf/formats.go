@@ -0,0 +1,377 @@
+package conf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/maps"
+	"github.com/knadh/koanf/parsers/hcl"
+	"github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/toml"
+	"github.com/knadh/koanf/parsers/yaml"
+)
+
+var (
+	formatsMu sync.RWMutex
+	formats   = make(map[string]koanf.Parser)
+)
+
+func init() {
+	RegisterFormat(yaml.Parser(), "yaml", "yml")
+	RegisterFormat(json.Parser(), "json")
+	RegisterFormat(toml.Parser(), "toml")
+	RegisterFormat(hcl.Parser(true), "hcl")
+	RegisterFormat(INIParser(), "ini")
+	RegisterFormat(PropertiesParser(), "properties")
+	RegisterFormat(DotEnvParser(), "env", "dotenv")
+}
+
+// RegisterFormat registers a koanf parser for one or more formats. Formats are matched
+// case insensitively with or without a leading dot so a format can be used both as the
+// format name in ParseBytes and the file extension in ParseFile. Registering an existing
+// format replaces it.
+func RegisterFormat(p koanf.Parser, names ...string) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	for _, name := range names {
+		formats[normalizeFormat(name)] = p
+	}
+}
+
+// Formats returns the sorted list of registered formats.
+func Formats() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	out := make([]string, 0, len(formats))
+	for name := range formats {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// formatParser returns the koanf parser for a format or file extension.
+func formatParser(format string) (koanf.Parser, error) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	if p, ok := formats[normalizeFormat(format)]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("unknown config format %s", format)
+}
+
+// normalizeFormat lowercases a format and removes any leading dot.
+func normalizeFormat(format string) string {
+	return strings.ToLower(strings.TrimPrefix(format, "."))
+}
+
+// INI parses INI files. Sections become nested keys with dotted section names
+// nesting further. Keys before any section are top level. Lines beginning with
+// ; or # are comments and values may be quoted.
+type INI struct{}
+
+// INIParser returns an INI parser.
+func INIParser() *INI {
+	return &INI{}
+}
+
+// Unmarshal parses INI bytes.
+func (p *INI) Unmarshal(b []byte) (map[string]interface{}, error) {
+
+	flat := make(map[string]interface{})
+	var section string
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == ';' || text[0] == '#' {
+			continue
+		}
+		if text[0] == '[' {
+			if text[len(text)-1] != ']' {
+				return nil, fmt.Errorf("invalid section on line %d", line)
+			}
+			section = strings.TrimSpace(text[1 : len(text)-1])
+			continue
+		}
+		key, value, found := strings.Cut(text, "=")
+		if !found {
+			return nil, fmt.Errorf("expected key = value on line %d", line)
+		}
+		flat[joinKeyDelimiter(section, strings.TrimSpace(key), DefaultDelimiter)] = unquote(strings.TrimSpace(value))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return maps.Unflatten(flat, DefaultDelimiter), nil
+}
+
+// Marshal marshals a config map to INI bytes. Top level values are written first
+// followed by a section for each nested map using dotted section names.
+func (p *INI) Marshal(o map[string]interface{}) ([]byte, error) {
+
+	var b bytes.Buffer
+	sections := make(map[string]map[string]interface{})
+
+	flat, _ := maps.Flatten(o, nil, DefaultDelimiter)
+	for key, value := range flat {
+		section, name := "", key
+		if i := strings.LastIndex(key, DefaultDelimiter); i >= 0 {
+			section, name = key[:i], key[i+1:]
+		}
+		if sections[section] == nil {
+			sections[section] = make(map[string]interface{})
+		}
+		sections[section][name] = value
+	}
+
+	for _, section := range sortedKeys(sections) {
+		if section != "" {
+			if b.Len() > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "[%s]\n", section)
+		}
+		values := sections[section]
+		for _, name := range sortedKeys(values) {
+			fmt.Fprintf(&b, "%s = %s\n", name, formatScalar(values[name], false))
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// Properties parses Java properties files. Dotted keys become nested keys. Keys and values
+// may be separated by =, : or whitespace, lines beginning with # or ! are comments, lines
+// ending in a backslash are continued and the usual escapes are supported.
+type Properties struct{}
+
+// PropertiesParser returns a Java properties parser.
+func PropertiesParser() *Properties {
+	return &Properties{}
+}
+
+// Unmarshal parses Java properties bytes.
+func (p *Properties) Unmarshal(b []byte) (map[string]interface{}, error) {
+
+	flat := make(map[string]interface{})
+	var logical strings.Builder
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		text := strings.TrimLeft(scanner.Text(), " \t\f")
+		if logical.Len() == 0 && (text == "" || text[0] == '#' || text[0] == '!') {
+			continue
+		}
+		// Continued lines end with an odd number of backslashes.
+		trailing := len(text) - len(strings.TrimRight(text, `\`))
+		if trailing%2 == 1 {
+			logical.WriteString(text[:len(text)-1])
+			continue
+		}
+		logical.WriteString(text)
+		if err := addProperty(flat, logical.String()); err != nil {
+			return nil, err
+		}
+		logical.Reset()
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// The last line may end with a continuation.
+	if logical.Len() > 0 {
+		if err := addProperty(flat, logical.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	return maps.Unflatten(flat, DefaultDelimiter), nil
+}
+
+// addProperty unescapes the key and value of a logical line and adds them to flat.
+func addProperty(flat map[string]interface{}, line string) error {
+	key, value := splitProperty(line)
+	key, err := unescapeProperty(key)
+	if err != nil {
+		return err
+	}
+	if value, err = unescapeProperty(value); err != nil {
+		return err
+	}
+	flat[key] = value
+	return nil
+}
+
+// Marshal marshals a config map to Java properties bytes with dotted keys.
+func (p *Properties) Marshal(o map[string]interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	flat, _ := maps.Flatten(o, nil, DefaultDelimiter)
+	replacer := strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "=", `\=`, ":", `\:`)
+	for _, key := range sortedKeys(flat) {
+		fmt.Fprintf(&b, "%s=%s\n", replacer.Replace(key), replacer.Replace(formatScalar(flat[key], true)))
+	}
+	return b.Bytes(), nil
+}
+
+// splitProperty splits a property line into the key and value at the first unescaped
+// separator (=, : or whitespace).
+func splitProperty(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++ // Skip the escaped character
+		case '=', ':', ' ', '\t', '\f':
+			rest := strings.TrimLeft(line[i:], " \t\f")
+			if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+				rest = rest[1:]
+			}
+			return line[:i], strings.TrimLeft(rest, " \t\f")
+		}
+	}
+	return line, ""
+}
+
+// unescapeProperty handles the escapes in a properties key or value.
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", fmt.Errorf("invalid unicode escape in %q", s)
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape in %q", s)
+			}
+			b.WriteRune(rune(r))
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// DotEnv parses .env files of KEY=value lines. Keys are lowercased and both a double
+// underscore and a dot nest keys so DATABASE__USER_PASSWORD sets database.user_password.
+// An optional leading export is ignored, # starts a comment and values may be quoted.
+type DotEnv struct{}
+
+// DotEnvParser returns a .env parser.
+func DotEnvParser() *DotEnv {
+	return &DotEnv{}
+}
+
+// Unmarshal parses .env bytes.
+func (p *DotEnv) Unmarshal(b []byte) (map[string]interface{}, error) {
+
+	flat := make(map[string]interface{})
+	keyReplacer := strings.NewReplacer("__", DefaultDelimiter)
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+		key, value, found := strings.Cut(text, "=")
+		if !found {
+			return nil, fmt.Errorf("expected KEY=value on line %d", line)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) > 0 && value[0] != '"' && value[0] != '\'' {
+			// Strip trailing comments from unquoted values.
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		flat[keyReplacer.Replace(strings.ToLower(strings.TrimSpace(key)))] = unquote(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return maps.Unflatten(flat, DefaultDelimiter), nil
+}
+
+// Marshal marshals a config map to .env bytes.
+func (p *DotEnv) Marshal(o map[string]interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	flat, _ := maps.Flatten(o, nil, DefaultDelimiter)
+	for _, key := range sortedKeys(flat) {
+		fmt.Fprintf(&b, "%s=%s\n", strings.ToUpper(strings.ReplaceAll(key, DefaultDelimiter, "__")), formatScalar(flat[key], false))
+	}
+	return b.Bytes(), nil
+}
+
+// unquote removes matching single or double quotes from a value. Double quoted values
+// support Go escapes.
+func unquote(s string) string {
+	if len(s) < 2 {
+		return s
+	}
+	switch {
+	case s[0] == '"' && s[len(s)-1] == '"':
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			return unquoted
+		}
+		return s[1 : len(s)-1]
+	case s[0] == '\'' && s[len(s)-1] == '\'':
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// formatScalar formats a value for line based formats. Slices are space separated
+// like environment variables. Strings are quoted if needed unless raw is set.
+func formatScalar(v interface{}, raw bool) string {
+	var s string
+	switch value := v.(type) {
+	case []interface{}:
+		parts := make([]string, len(value))
+		for i, part := range value {
+			parts[i] = fmt.Sprint(part)
+		}
+		s = strings.Join(parts, " ")
+	default:
+		s = fmt.Sprint(value)
+	}
+	if !raw && (s != strings.TrimSpace(s) || strings.ContainsAny(s, "\"'#;\n")) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// sortedKeys returns the sorted keys of a map.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
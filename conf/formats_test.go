@@ -0,0 +1,164 @@
+package conf
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/knadh/koanf"
+)
+
+func TestFormatsUnmarshal(t *testing.T) {
+	for name, test := range map[string]struct {
+		parser koanf.Parser
+		in     string
+		want   map[string]interface{}
+		err    bool
+	}{
+		"ini sections": {
+			parser: INIParser(),
+			in:     "name = app\n; comment\n# comment\n[server]\nport = 8080\n[server.tls]\ncert = \"a b\"\nkey = 'c'\n",
+			want: map[string]interface{}{
+				"name":   "app",
+				"server": map[string]interface{}{"port": "8080", "tls": map[string]interface{}{"cert": "a b", "key": "c"}},
+			},
+		},
+		"ini escapes": {
+			parser: INIParser(),
+			in:     "a = \"x\\ty\"\nb = x=y\n",
+			want:   map[string]interface{}{"a": "x\ty", "b": "x=y"},
+		},
+		"ini invalid section": {parser: INIParser(), in: "[server\n", err: true},
+		"ini missing value":   {parser: INIParser(), in: "name\n", err: true},
+		"properties": {
+			parser: PropertiesParser(),
+			in:     "# comment\n! comment\nserver.port=8080\nserver.host : localhost\nname app\n",
+			want: map[string]interface{}{
+				"server": map[string]interface{}{"port": "8080", "host": "localhost"},
+				"name":   "app",
+			},
+		},
+		"properties escapes": {
+			parser: PropertiesParser(),
+			in:     "a\\=b=c\\:d\nnl=x\\ny\ntab=\\t\nuni=\\u00e9\nslash=a\\\\\n",
+			want:   map[string]interface{}{"a=b": "c:d", "nl": "x\ny", "tab": "\t", "uni": "é", "slash": `a\`},
+		},
+		"properties continuation": {
+			parser: PropertiesParser(),
+			in:     "list=a, \\\n    b, \\\n    c\nnext=d\n",
+			want:   map[string]interface{}{"list": "a, b, c", "next": "d"},
+		},
+		"properties trailing continuation": {
+			parser: PropertiesParser(),
+			in:     "a\\tb=x\\n\\\n  y\\\\\\",
+			want:   map[string]interface{}{"a\tb": "x\ny\\"},
+		},
+		"properties invalid unicode": {parser: PropertiesParser(), in: "a=\\u12\n", err: true},
+		"dotenv": {
+			parser: DotEnvParser(),
+			in:     "# comment\nexport NAME=app\nDATABASE__USER_PASSWORD=\"p#ss\\n\"\nSERVER.PORT=8080 # port\nQUOTED='a # b'\n",
+			want: map[string]interface{}{
+				"name":     "app",
+				"database": map[string]interface{}{"user_password": "p#ss\n"},
+				"server":   map[string]interface{}{"port": "8080"},
+				"quoted":   "a # b",
+			},
+		},
+		"dotenv missing value": {parser: DotEnvParser(), in: "NAME\n", err: true},
+		"hcl": {
+			parser: hclParser(t),
+			in:     "name = \"app\"\nserver {\n  port = 8080\n}\n",
+			want: map[string]interface{}{
+				"name":   "app",
+				"server": map[string]interface{}{"port": 8080},
+			},
+		},
+	} {
+		got, err := test.parser.Unmarshal([]byte(test.in))
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %#v, want %#v", name, got, test.want)
+		}
+	}
+}
+
+func TestFormatsRoundTrip(t *testing.T) {
+	in := map[string]interface{}{
+		"name":   "a b=c:d\n",
+		"server": map[string]interface{}{"port": "8080", "tls": map[string]interface{}{"cert": "x # y"}},
+	}
+	for _, format := range []string{"ini", "properties", "env"} {
+		p, err := formatParser(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := p.Marshal(in)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		got, err := p.Unmarshal(b)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if !reflect.DeepEqual(got, in) {
+			t.Errorf("%s: got %#v from %q, want %#v", format, got, b, in)
+		}
+	}
+}
+
+func TestFormatRegistry(t *testing.T) {
+	for format, want := range map[string]interface{}{
+		"yaml":        nil,
+		".YML":        nil,
+		"ini":         INIParser(),
+		".Properties": PropertiesParser(),
+		"dotenv":      DotEnvParser(),
+		".env":        DotEnvParser(),
+	} {
+		p, err := formatParser(format)
+		if err != nil {
+			t.Errorf("%s: %v", format, err)
+			continue
+		}
+		if want != nil && reflect.TypeOf(p) != reflect.TypeOf(want) {
+			t.Errorf("%s: parser %T, want %T", format, p, want)
+		}
+	}
+	if _, err := formatParser("xml"); err == nil {
+		t.Error("xml: expected an error")
+	}
+
+	RegisterFormat(PropertiesParser(), ".Conf")
+	t.Cleanup(func() {
+		formatsMu.Lock()
+		delete(formats, "conf")
+		formatsMu.Unlock()
+	})
+	if p, err := formatParser("conf"); err != nil || reflect.TypeOf(p) != reflect.TypeOf(PropertiesParser()) {
+		t.Errorf("conf: parser %T, %v, want the registered parser", p, err)
+	}
+	found := false
+	for _, format := range Formats() {
+		found = found || format == "conf"
+	}
+	if !found {
+		t.Errorf("Formats() = %v, want it to include conf", Formats())
+	}
+}
+
+// hclParser returns the registered hcl parser.
+func hclParser(t *testing.T) koanf.Parser {
+	p, err := formatParser("hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
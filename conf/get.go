@@ -0,0 +1,41 @@
+package conf
+
+import (
+	"fmt"
+)
+
+// Get returns the value of key in c decoded into T. It decodes using the same
+// DefaultDecodeHooks as Unmarshal so durations, IPs, decimals, slog levels and
+// so on convert exactly like they do in structs. If the key is not set or cannot
+// be decoded into T, def is returned. Use GetE to get the error instead.
+func Get[T any](c *Conf, key string, def T) T {
+	value, err := GetE[T](c, key)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// GetE returns the value of key in c decoded into T. It returns an error if the
+// key is not set or cannot be decoded into T. See Get.
+func GetE[T any](c *Conf, key string) (T, error) {
+	var value T
+	k := c.Current()
+	if !k.Exists(key) {
+		return value, fmt.Errorf("config key %s is not set", key)
+	}
+	if err := Decode(k.Get(key), &value, WithTagName(c.Tag)); err != nil {
+		return value, fmt.Errorf("could not decode %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// MustGet returns the value of key in c decoded into T. It panics if the key is
+// not set or cannot be decoded into T. See Get.
+func MustGet[T any](c *Conf, key string) T {
+	value, err := GetE[T](c, key)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
@@ -1,10 +1,19 @@
 package conf
 
 import (
+	"crypto/x509"
+	"encoding"
 	"fmt"
+	"io/fs"
 	"log/slog"
+	"math"
+	"net/netip"
+	"net/url"
+	"os"
 	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
@@ -15,8 +24,10 @@ import (
 
 // DefaultDecodeHooks are the default decoding hooks used to unmarshal into a struct.
 // This includes hooks for parsing string to time.Duration, time.Time(RFC3339 format),
-// net.IP and net.IPNet. You can use this function to grab the defaults plus add your
-// own with the extras option.
+// net.IP, net.IPNet, decimal.Decimal, slog.Level, url.URL, netip.Addr, netip.Prefix,
+// regexp.Regexp, ByteSize, time.Location, fs.FileMode, x509.CertPool and anything
+// implementing encoding.TextUnmarshaler. You can use this function to grab the
+// defaults plus add your own with the extras option.
 func DefaultDecodeHooks(extras ...mapstructure.DecodeHookFunc) []mapstructure.DecodeHookFunc {
 	return append([]mapstructure.DecodeHookFunc{
 		mapstructure.RecursiveStructToMapHookFunc(),
@@ -26,6 +37,15 @@ func DefaultDecodeHooks(extras ...mapstructure.DecodeHookFunc) []mapstructure.De
 		mapstructure.StringToIPNetHookFunc(),
 		DecimalHookFunc(),
 		SLogLevelHookFunc(),
+		URLHookFunc(),
+		NetIPAddrHookFunc(),
+		NetIPPrefixHookFunc(),
+		RegexpHookFunc(),
+		ByteSizeHookFunc(),
+		LocationHookFunc(),
+		FileModeHookFunc(),
+		CertPoolHookFunc(),
+		TextUnmarshalerHookFunc(),
 	}, extras...)
 }
 
@@ -75,3 +95,225 @@ func SLogLevelHookFunc() mapstructure.DecodeHookFunc {
 
 	}
 }
+
+// URLHookFunc decodes string config into a url.URL.
+func URLHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, v interface{}) (interface{}, error) {
+		if t != reflect.TypeOf(url.URL{}) || f.Kind() != reflect.String {
+			return v, nil
+		}
+		u, err := url.Parse(v.(string))
+		if err != nil {
+			return nil, fmt.Errorf("invalid url: %w", err)
+		}
+		return *u, nil
+	}
+}
+
+// NetIPAddrHookFunc decodes string config into a netip.Addr.
+func NetIPAddrHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, v interface{}) (interface{}, error) {
+		if t != reflect.TypeOf(netip.Addr{}) || f.Kind() != reflect.String {
+			return v, nil
+		}
+		if v.(string) == "" {
+			return netip.Addr{}, nil
+		}
+		return netip.ParseAddr(v.(string))
+	}
+}
+
+// NetIPPrefixHookFunc decodes string config into a netip.Prefix.
+func NetIPPrefixHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, v interface{}) (interface{}, error) {
+		if t != reflect.TypeOf(netip.Prefix{}) || f.Kind() != reflect.String {
+			return v, nil
+		}
+		if v.(string) == "" {
+			return netip.Prefix{}, nil
+		}
+		return netip.ParsePrefix(v.(string))
+	}
+}
+
+// RegexpHookFunc decodes string config into a regexp.Regexp.
+func RegexpHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, v interface{}) (interface{}, error) {
+		if t != reflect.TypeOf(regexp.Regexp{}) || f.Kind() != reflect.String {
+			return v, nil
+		}
+		re, err := regexp.Compile(v.(string))
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %w", err)
+		}
+		return *re, nil
+	}
+}
+
+// ByteSizeHookFunc decodes string config like "512MiB" into a ByteSize. See ParseByteSize.
+func ByteSizeHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, v interface{}) (interface{}, error) {
+		if t != reflect.TypeOf(ByteSize(0)) || f.Kind() != reflect.String {
+			return v, nil
+		}
+		return ParseByteSize(v.(string))
+	}
+}
+
+// LocationHookFunc decodes string config like "America/Chicago" into a time.Location.
+func LocationHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, v interface{}) (interface{}, error) {
+		if t != reflect.TypeOf(time.Location{}) || f.Kind() != reflect.String {
+			return v, nil
+		}
+		loc, err := time.LoadLocation(v.(string))
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone: %w", err)
+		}
+		return *loc, nil
+	}
+}
+
+// FileModeHookFunc decodes string config into an fs.FileMode. Strings are always
+// octal so "644", "0644" and "0o644" are the same.
+func FileModeHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, v interface{}) (interface{}, error) {
+		if t != reflect.TypeOf(fs.FileMode(0)) || f.Kind() != reflect.String {
+			return v, nil
+		}
+		s := strings.TrimPrefix(strings.TrimPrefix(v.(string), "0o"), "0O")
+		mode, err := strconv.ParseUint(s, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file mode %q: must be octal", v)
+		}
+		return fs.FileMode(mode), nil
+	}
+}
+
+// CertPoolHookFunc decodes a PEM file path or list of paths into an x509.CertPool
+// containing every certificate in the files.
+func CertPoolHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, v interface{}) (interface{}, error) {
+		if t != reflect.TypeOf(x509.CertPool{}) {
+			return v, nil
+		}
+		var paths []string
+		switch f.Kind() {
+		case reflect.String:
+			paths = []string{v.(string)}
+		case reflect.Slice, reflect.Array:
+			var err error
+			if paths, err = cast.ToStringSliceE(v); err != nil {
+				return nil, fmt.Errorf("invalid certificate paths: %w", err)
+			}
+		default:
+			return v, nil
+		}
+		pool := x509.NewCertPool()
+		for _, path := range paths {
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("could not read certificates: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(b) {
+				return nil, fmt.Errorf("no certificates found in %s", path)
+			}
+		}
+		return *pool, nil
+	}
+}
+
+// TextUnmarshalerHookFunc decodes string config into any type implementing
+// encoding.TextUnmarshaler.
+func TextUnmarshalerHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, v interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t.Kind() == reflect.Ptr {
+			return v, nil
+		}
+		result := reflect.New(t)
+		unmarshaler, ok := result.Interface().(encoding.TextUnmarshaler)
+		if !ok {
+			return v, nil
+		}
+		if err := unmarshaler.UnmarshalText([]byte(reflect.ValueOf(v).String())); err != nil {
+			return nil, err
+		}
+		return result.Elem().Interface(), nil
+	}
+}
+
+// ByteSize is a size in bytes that decodes from strings like "512MiB" or "1.5GB".
+type ByteSize uint64
+
+// Byte size units.
+const (
+	Byte ByteSize = 1
+	KB            = 1000 * Byte
+	MB            = 1000 * KB
+	GB            = 1000 * MB
+	TB            = 1000 * GB
+	PB            = 1000 * TB
+	KiB           = 1024 * Byte
+	MiB           = 1024 * KiB
+	GiB           = 1024 * MiB
+	TiB           = 1024 * GiB
+	PiB           = 1024 * TiB
+)
+
+var byteSizeUnits = map[string]ByteSize{
+	"": Byte, "b": Byte,
+	"k": KB, "kb": KB, "m": MB, "mb": MB, "g": GB, "gb": GB, "t": TB, "tb": TB, "p": PB, "pb": PB,
+	"ki": KiB, "kib": KiB, "mi": MiB, "mib": MiB, "gi": GiB, "gib": GiB, "ti": TiB, "tib": TiB, "pi": PiB, "pib": PiB,
+}
+
+// ParseByteSize parses a size like "512MiB", "1.5GB" or "1024". Units are case
+// insensitive. KB, MB, etc are powers of 1000 and KiB, MiB, etc are powers of 1024.
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	number, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	multiplier, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid byte size %q: unknown unit %s", s, s[i:])
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	size := n * float64(multiplier)
+	if size > math.MaxUint64 {
+		return 0, fmt.Errorf("invalid byte size %q: too large", s)
+	}
+	return ByteSize(size), nil
+}
+
+// String returns the size using the largest binary unit that represents it exactly.
+func (b ByteSize) String() string {
+	for _, u := range []struct {
+		size ByteSize
+		name string
+	}{{PiB, "PiB"}, {TiB, "TiB"}, {GiB, "GiB"}, {MiB, "MiB"}, {KiB, "KiB"}} {
+		if b >= u.size && b%u.size == 0 {
+			return strconv.FormatUint(uint64(b/u.size), 10) + u.name
+		}
+	}
+	return strconv.FormatUint(uint64(b), 10) + "B"
+}
+
+// MarshalText returns the size as text. See String.
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText parses the size. See ParseByteSize.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
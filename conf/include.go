@@ -0,0 +1,144 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/spf13/cast"
+	"github.com/spf13/pflag"
+)
+
+// IncludeKey is the key in a config file that lists other files to include.
+const IncludeKey = "include"
+
+// WithFileProfile parses a configuration file and its profile overlay.
+// See ParseFileProfile for more information.
+func WithFileProfile(configFile string, profile string) ParserFunc {
+	return func(c *Conf) error {
+		return c.ParseFileProfile(configFile, profile)
+	}
+}
+
+// WithFileProfileEnv parses a configuration file and the profile overlay named
+// by the environment variable envVar. See ParseFileProfile for more information.
+func WithFileProfileEnv(configFile string, envVar string) ParserFunc {
+	return func(c *Conf) error {
+		return c.ParseFileProfile(configFile, os.Getenv(envVar))
+	}
+}
+
+// ParseFileProfile parses configFile and then overlays the profile file next to it. For
+// example app.yaml with profile staging will load app.yaml and then app.staging.yaml.
+// If profile is empty or the profile file does not exist, only configFile is loaded.
+func (c *Conf) ParseFileProfile(configFile string, profile string) error {
+	if err := c.ParseFile(configFile); err != nil {
+		return err
+	}
+	if configFile == "" || profile == "" {
+		return nil
+	}
+	profileFile := ProfileFile(configFile, profile)
+	if _, err := os.Stat(profileFile); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return c.ParseFile(profileFile)
+}
+
+// ProfileFile returns the name of the profile overlay file for configFile.
+func ProfileFile(configFile string, profile string) string {
+	ext := filepath.Ext(configFile)
+	return strings.TrimSuffix(configFile, ext) + "." + profile + ext
+}
+
+// Profile returns the selected profile. The flag flagName is used if it was set on the
+// command line, otherwise the environment variable envVar if it is set, otherwise the
+// default value of the flag. Either flagSet or envVar may be empty.
+func Profile(flagSet *pflag.FlagSet, flagName string, envVar string) string {
+	var f *pflag.Flag
+	if flagSet != nil {
+		f = flagSet.Lookup(flagName)
+	}
+	if f != nil && f.Changed {
+		return f.Value.String()
+	}
+	if envVar != "" {
+		if profile, ok := os.LookupEnv(envVar); ok {
+			return profile
+		}
+	}
+	if f != nil {
+		return f.Value.String()
+	}
+	return ""
+}
+
+// parseFile loads a config file and any files it includes. Included files are loaded
+// before the file including them so the including file takes precedence. Include paths
+// are relative to the including file and may be globs. stack is the chain of files
+// including this one and is used to detect cycles.
+func (c *Conf) parseFile(configFile string, stack []string) error {
+
+	absFile, err := filepath.Abs(configFile)
+	if err != nil {
+		return fmt.Errorf("could not resolve path %s: %w", configFile, err)
+	}
+	for i, parent := range stack {
+		if parent == absFile {
+			return fmt.Errorf("config include cycle: %s", strings.Join(append(stack[i:], absFile), " -> "))
+		}
+	}
+
+	format := filepath.Ext(configFile)
+	if _, err := formatParser(format); err != nil {
+		return err
+	}
+	c.addFile(configFile)
+
+	b, err := os.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
+	config, decrypted, err := c.unmarshalBytes(b, format)
+	if err != nil {
+		return fmt.Errorf("could not parse %s: %w", configFile, err)
+	}
+
+	// Load any includes first.
+	if include, ok := config[IncludeKey]; ok {
+		delete(config, IncludeKey)
+		includes, err := cast.ToStringSliceE(include)
+		if s, ok := include.(string); ok {
+			includes, err = []string{s}, nil // Don't split a single path on spaces
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s in %s: %w", IncludeKey, configFile, err)
+		}
+		for _, pattern := range includes {
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(filepath.Dir(configFile), pattern)
+			}
+			files := []string{pattern}
+			if strings.ContainsAny(pattern, "*?[") {
+				if files, err = filepath.Glob(pattern); err != nil {
+					return fmt.Errorf("invalid %s pattern %s in %s: %w", IncludeKey, pattern, configFile, err)
+				}
+			}
+			for _, file := range files {
+				if err := c.parseFile(file, append(stack, absFile)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if err := c.load(Source{Parser: "file", Name: configFile}, nil, confmap.Provider(config, ""), nil); err != nil {
+		return err
+	}
+	c.markResolved(decrypted...)
+	return nil
+}
@@ -0,0 +1,196 @@
+package conf
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// WithInterpolation enables expanding placeholders once all of the parsers have run.
+// See Interpolate for more information.
+func WithInterpolation() ParserFunc {
+	return func(c *Conf) error {
+		c.SetInterpolation(true)
+		return nil
+	}
+}
+
+// SetInterpolation sets whether Parse expands placeholders with Interpolate. It's
+// disabled by default so existing values containing ${ are left alone.
+func (c *Conf) SetInterpolation(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interpolate = enabled
+}
+
+// Interpolate expands ${name} and ${name:-default} placeholders in string values. If name
+// is a config key its value is used, otherwise the environment variable name is used. As
+// in the shell, default is used if the value is unset or empty. Anything else is an error,
+// as are references that form a cycle. Use $${ for a literal ${. If a value is nothing but a
+// single placeholder for a config key it keeps the type of that key's value. Values
+// that reference a secret key are also marked secret. Values set by a resolver or
+// decryption are never expanded. Parse calls this for you if enabled with WithInterpolation.
+func (c *Conf) Interpolate() error {
+
+	in := &interpolator{
+		c:        c,
+		all:      c.All(),
+		done:     make(map[string]interface{}),
+		secrets:  make(map[string]bool),
+		resolved: make(map[string]bool),
+	}
+	c.mu.RLock()
+	for key := range c.resolved {
+		in.resolved[key] = true
+	}
+	c.mu.RUnlock()
+
+	keys := make([]string, 0, len(in.all))
+	for key := range in.all {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if _, err := in.key(key, nil); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range in.changed {
+		if err := c.Set(key, in.done[key]); err != nil {
+			return fmt.Errorf("could not set %s: %w", key, err)
+		}
+		if in.secrets[key] {
+			c.MarkSecret(key)
+		}
+	}
+
+	return nil
+}
+
+// interpolator tracks the state of interpolating a config.
+type interpolator struct {
+	c        *Conf
+	all      map[string]interface{}
+	done     map[string]interface{}
+	secrets  map[string]bool
+	resolved map[string]bool
+	changed  []string
+}
+
+// key returns the interpolated value of key. stack is the chain of keys referencing it.
+func (in *interpolator) key(key string, stack []string) (interface{}, error) {
+
+	if value, ok := in.done[key]; ok {
+		return value, nil
+	}
+	for i, k := range stack {
+		if k == key {
+			return nil, fmt.Errorf("could not interpolate %s: reference cycle %s", stack[0], strings.Join(append(stack[i:], key), " -> "))
+		}
+	}
+	stack = append(stack, key)
+
+	secret := in.c.IsSecret(key)
+	value := in.all[key]
+	changed := false
+	if in.resolved[key] {
+		in.done[key] = value
+		in.secrets[key] = secret
+		return value, nil
+	}
+	switch v := value.(type) {
+	case string:
+		if strings.Contains(v, "${") {
+			expanded, isSecret, err := in.expand(v, stack)
+			if err != nil {
+				return nil, err
+			}
+			value, changed, secret = expanded, true, secret || isSecret
+		}
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = elem
+			if s, ok := elem.(string); ok && strings.Contains(s, "${") {
+				expanded, isSecret, err := in.expand(s, stack)
+				if err != nil {
+					return nil, err
+				}
+				out[i], changed, secret = expanded, true, secret || isSecret
+			}
+		}
+		value = out
+	}
+
+	in.done[key] = value
+	in.secrets[key] = secret
+	if changed {
+		in.changed = append(in.changed, key)
+	}
+	return value, nil
+}
+
+// expand expands the placeholders in s. It returns true if it referenced a secret.
+func (in *interpolator) expand(s string, stack []string) (interface{}, bool, error) {
+
+	var (
+		b      strings.Builder
+		secret bool
+		key    = stack[len(stack)-1]
+	)
+
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			break
+		}
+		// Escaped with $${
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+		end := strings.Index(s[i:], "}")
+		if end < 0 {
+			return nil, false, fmt.Errorf("could not interpolate %s: unterminated placeholder in %q", key, s)
+		}
+		end += i
+		name, def, hasDefault := strings.Cut(s[i+2:end], ":-")
+
+		var (
+			value     interface{}
+			keySecret bool
+		)
+		if _, ok := in.all[name]; ok {
+			v, err := in.key(name, stack)
+			if err != nil {
+				return nil, false, err
+			}
+			value, keySecret = v, in.secrets[name]
+		} else if env, ok := os.LookupEnv(name); ok {
+			value = env
+		} else if !hasDefault {
+			return nil, false, fmt.Errorf("could not interpolate %s: unresolved reference ${%s}", key, name)
+		}
+		// Like the shell, empty values use the default too.
+		if hasDefault && (value == nil || value == "") {
+			value, keySecret = def, false
+		}
+		secret = secret || keySecret
+
+		// Keep the type if the whole value is a single placeholder.
+		if b.Len() == 0 && i == 0 && end == len(s)-1 {
+			return value, secret, nil
+		}
+
+		b.WriteString(s[:i])
+		b.WriteString(fmt.Sprint(value))
+		s = s[end+1:]
+	}
+
+	return b.String(), secret, nil
+}
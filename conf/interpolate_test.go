@@ -0,0 +1,83 @@
+package conf
+
+import (
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("INTERPOLATE_TEST_HOST", "example.com")
+	t.Setenv("INTERPOLATE_TEST_EMPTY", "")
+
+	c := New()
+	if err := c.Parse(WithInterpolation(), WithMap(map[string]interface{}{
+		"host":    "${INTERPOLATE_TEST_HOST}",
+		"port":    8080,
+		"url":     "http://${host}:${port}",
+		"copy":    "${port}",
+		"default": "${INTERPOLATE_TEST_MISSING:-none}",
+		"empty":   "${INTERPOLATE_TEST_EMPTY:-none}",
+		"blank":   "",
+		"unset":   "${blank:-none}",
+		"set":     "${INTERPOLATE_TEST_EMPTY}",
+		"escaped": "$${host}",
+	})); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]interface{}{
+		"host":    "example.com",
+		"url":     "http://example.com:8080",
+		"copy":    8080,
+		"default": "none",
+		"empty":   "none",
+		"unset":   "none",
+		"set":     "",
+		"escaped": "${host}",
+	} {
+		if got := c.Get(key); got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestInterpolateErrors(t *testing.T) {
+	for name, config := range map[string]map[string]interface{}{
+		"unresolved": {"a": "${interpolate_test_missing}"},
+		"cycle":      {"a": "${b}", "b": "${a}"},
+	} {
+		if err := New().Parse(WithInterpolation(), WithMap(config)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestInterpolateDisabled(t *testing.T) {
+	c := New()
+	if err := c.Parse(WithMap(map[string]interface{}{"a": "${b}"})); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("a"); got != "${b}" {
+		t.Fatalf("a = %q, want it unchanged", got)
+	}
+}
+
+func TestInterpolateSkipsResolved(t *testing.T) {
+	t.Setenv("INTERPOLATE_TEST_PASSWORD", "ab${cd}")
+
+	c := New()
+	c.RegisterDefaultResolvers()
+	if err := c.Parse(WithInterpolation(), WithMap(map[string]interface{}{
+		"password": "env://INTERPOLATE_TEST_PASSWORD",
+		"dsn":      "user:${password}@host",
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("password"); got != "ab${cd}" {
+		t.Errorf("password = %q, want it unchanged", got)
+	}
+	if got := c.String("dsn"); got != "user:ab${cd}@host" {
+		t.Errorf("dsn = %q", got)
+	}
+	if !c.IsSecret("dsn") {
+		t.Error("dsn should be secret as it references a secret")
+	}
+}
@@ -0,0 +1,304 @@
+package conf
+
+import (
+	"time"
+
+	"github.com/knadh/koanf"
+)
+
+// Conf embeds koanf.Koanf but Reload swaps it and koanf is not safe for concurrent use.
+// The methods below shadow koanf's so reads use the current instance under a lock and
+// writes copy it, apply the change and swap the copy in. Never modify the embedded
+// instance directly, and use Current rather than reading the field.
+
+// update applies f to a copy of the current configuration and swaps the copy in.
+func (c *Conf) update(f func(k *koanf.Koanf) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := c.Koanf.Copy()
+	if err := f(k); err != nil {
+		return err
+	}
+	c.Koanf = k
+	return nil
+}
+
+// Merge merges the config map of a given Koanf instance into the current configuration.
+func (c *Conf) Merge(in *koanf.Koanf) error {
+	return c.update(func(k *koanf.Koanf) error {
+		return k.Merge(in)
+	})
+}
+
+// MergeAt merges the config map of a given Koanf instance into the current configuration
+// at the given key path.
+func (c *Conf) MergeAt(in *koanf.Koanf, path string) error {
+	return c.update(func(k *koanf.Koanf) error {
+		return k.MergeAt(in, path)
+	})
+}
+
+// Set sets the value at a specific key.
+func (c *Conf) Set(key string, val interface{}) error {
+	return c.update(func(k *koanf.Koanf) error {
+		return k.Set(key, val)
+	})
+}
+
+// Delete removes all nested values from a given path.
+func (c *Conf) Delete(path string) {
+	_ = c.update(func(k *koanf.Koanf) error {
+		k.Delete(path)
+		return nil
+	})
+}
+
+// Int64 returns the int64 value of a given key path or 0 if the path does not exist or if the value is not a valid int64.
+func (c *Conf) Int64(path string) int64 {
+	return c.Current().Int64(path)
+}
+
+// MustInt64 returns the int64 value of a given key path or panics if the value is not set or set to default value of 0.
+func (c *Conf) MustInt64(path string) int64 {
+	return c.Current().MustInt64(path)
+}
+
+// Int64s returns the []int64 slice value of a given key path or an empty []int64 slice if the path does not exist or if the value is not a valid int slice.
+func (c *Conf) Int64s(path string) []int64 {
+	return c.Current().Int64s(path)
+}
+
+// MustInt64s returns the []int64 slice value of a given key path or panics if the value is not set or its default value.
+func (c *Conf) MustInt64s(path string) []int64 {
+	return c.Current().MustInt64s(path)
+}
+
+// Int64Map returns the map[string]int64 value of a given key path or an empty map[string]int64 if the path does not exist or if the value is not a valid int64 map.
+func (c *Conf) Int64Map(path string) map[string]int64 {
+	return c.Current().Int64Map(path)
+}
+
+// MustInt64Map returns the map[string]int64 value of a given key path or panics if its not set or set to default value.
+func (c *Conf) MustInt64Map(path string) map[string]int64 {
+	return c.Current().MustInt64Map(path)
+}
+
+// Int returns the int value of a given key path or 0 if the path does not exist or if the value is not a valid int.
+func (c *Conf) Int(path string) int {
+	return c.Current().Int(path)
+}
+
+// MustInt returns the int value of a given key path or panics or panics if its not set or set to default value of 0.
+func (c *Conf) MustInt(path string) int {
+	return c.Current().MustInt(path)
+}
+
+// Ints returns the []int slice value of a given key path or an empty []int slice if the path does not exist or if the value is not a valid int slice.
+func (c *Conf) Ints(path string) []int {
+	return c.Current().Ints(path)
+}
+
+// MustInts returns the []int slice value of a given key path or panics if the value is not set or set to default value.
+func (c *Conf) MustInts(path string) []int {
+	return c.Current().MustInts(path)
+}
+
+// IntMap returns the map[string]int value of a given key path or an empty map[string]int if the path does not exist or if the value is not a valid int map.
+func (c *Conf) IntMap(path string) map[string]int {
+	return c.Current().IntMap(path)
+}
+
+// MustIntMap returns the map[string]int value of a given key path or panics if the value is not set or set to default value.
+func (c *Conf) MustIntMap(path string) map[string]int {
+	return c.Current().MustIntMap(path)
+}
+
+// Float64 returns the float64 value of a given key path or 0 if the path does not exist or if the value is not a valid float64.
+func (c *Conf) Float64(path string) float64 {
+	return c.Current().Float64(path)
+}
+
+// MustFloat64 returns the float64 value of a given key path or panics or panics if its not set or set to default value 0.
+func (c *Conf) MustFloat64(path string) float64 {
+	return c.Current().MustFloat64(path)
+}
+
+// Float64s returns the []float64 slice value of a given key path or an empty []float64 slice if the path does not exist or if the value is not a valid float64 slice.
+func (c *Conf) Float64s(path string) []float64 {
+	return c.Current().Float64s(path)
+}
+
+// MustFloat64s returns the []Float64 slice value of a given key path or panics if the value is not set or set to default value.
+func (c *Conf) MustFloat64s(path string) []float64 {
+	return c.Current().MustFloat64s(path)
+}
+
+// Float64Map returns the map[string]float64 value of a given key path or an empty map[string]float64 if the path does not exist or if the value is not a valid float64 map.
+func (c *Conf) Float64Map(path string) map[string]float64 {
+	return c.Current().Float64Map(path)
+}
+
+// MustFloat64Map returns the map[string]float64 value of a given key path or panics if the value is not set or set to default value.
+func (c *Conf) MustFloat64Map(path string) map[string]float64 {
+	return c.Current().MustFloat64Map(path)
+}
+
+// Duration returns the time.Duration value of a given key path assuming that the key contains a valid numeric value.
+func (c *Conf) Duration(path string) time.Duration {
+	return c.Current().Duration(path)
+}
+
+// MustDuration returns the time.Duration value of a given key path or panics if its not set or set to default value 0.
+func (c *Conf) MustDuration(path string) time.Duration {
+	return c.Current().MustDuration(path)
+}
+
+// Time attempts to parse the value of a given key path and return time.Time representation.
+func (c *Conf) Time(path, layout string) time.Time {
+	return c.Current().Time(path, layout)
+}
+
+// MustTime attempts to parse the value of a given key path and return time.Time representation.
+func (c *Conf) MustTime(path, layout string) time.Time {
+	return c.Current().MustTime(path, layout)
+}
+
+// String returns the string value of a given key path or "" if the path does not exist or if the value is not a valid string.
+func (c *Conf) String(path string) string {
+	return c.Current().String(path)
+}
+
+// MustString returns the string value of a given key path or panics if its not set or set to default value "".
+func (c *Conf) MustString(path string) string {
+	return c.Current().MustString(path)
+}
+
+// Strings returns the []string slice value of a given key path or an empty []string slice if the path does not exist or if the value is not a valid string slice.
+func (c *Conf) Strings(path string) []string {
+	return c.Current().Strings(path)
+}
+
+// MustStrings returns the []string slice value of a given key path or panics if the value is not set or set to default value.
+func (c *Conf) MustStrings(path string) []string {
+	return c.Current().MustStrings(path)
+}
+
+// StringMap returns the map[string]string value of a given key path or an empty map[string]string if the path does not exist or if the value is not a valid string map.
+func (c *Conf) StringMap(path string) map[string]string {
+	return c.Current().StringMap(path)
+}
+
+// MustStringMap returns the map[string]string value of a given key path or panics if the value is not set or set to default value.
+func (c *Conf) MustStringMap(path string) map[string]string {
+	return c.Current().MustStringMap(path)
+}
+
+// StringsMap returns the map[string][]string value of a given key path or an empty map[string][]string if the path does not exist or if the value is not a valid strings map.
+func (c *Conf) StringsMap(path string) map[string][]string {
+	return c.Current().StringsMap(path)
+}
+
+// MustStringsMap returns the map[string][]string value of a given key path or panics if the value is not set or set to default value.
+func (c *Conf) MustStringsMap(path string) map[string][]string {
+	return c.Current().MustStringsMap(path)
+}
+
+// Bytes returns the []byte value of a given key path or an empty []byte slice if the path does not exist or if the value is not a valid string.
+func (c *Conf) Bytes(path string) []byte {
+	return c.Current().Bytes(path)
+}
+
+// MustBytes returns the []byte value of a given key path or panics if the value is not set or set to default value.
+func (c *Conf) MustBytes(path string) []byte {
+	return c.Current().MustBytes(path)
+}
+
+// Bool returns the bool value of a given key path or false if the path does not exist or if the value is not a valid bool representation.
+func (c *Conf) Bool(path string) bool {
+	return c.Current().Bool(path)
+}
+
+// Bools returns the []bool slice value of a given key path or an empty []bool slice if the path does not exist or if the value is not a valid bool slice.
+func (c *Conf) Bools(path string) []bool {
+	return c.Current().Bools(path)
+}
+
+// MustBools returns the []bool value of a given key path or panics if the value is not set or set to default value.
+func (c *Conf) MustBools(path string) []bool {
+	return c.Current().MustBools(path)
+}
+
+// BoolMap returns the map[string]bool value of a given key path or an empty map[string]bool if the path does not exist or if the value is not a valid bool map.
+func (c *Conf) BoolMap(path string) map[string]bool {
+	return c.Current().BoolMap(path)
+}
+
+// MustBoolMap returns the map[string]bool value of a given key path or panics if the value is not set or set to default value.
+func (c *Conf) MustBoolMap(path string) map[string]bool {
+	return c.Current().MustBoolMap(path)
+}
+
+// Keys returns the slice of all flattened keys in the loaded configuration sorted alphabetically.
+func (c *Conf) Keys() []string {
+	return c.Current().Keys()
+}
+
+// KeyMap returns a map of flattened keys and the individual parts of the key as slices.
+func (c *Conf) KeyMap() koanf.KeyMap {
+	return c.Current().KeyMap()
+}
+
+// All returns a map of all flattened key paths and their values.
+func (c *Conf) All() map[string]interface{} {
+	return c.Current().All()
+}
+
+// Raw returns a copy of the full raw conf map.
+func (c *Conf) Raw() map[string]interface{} {
+	return c.Current().Raw()
+}
+
+// Cut cuts the config map at a given key path into a sub map and returns a new Koanf instance with the cut config map loaded.
+func (c *Conf) Cut(path string) *koanf.Koanf {
+	return c.Current().Cut(path)
+}
+
+// Copy returns a copy of the Koanf instance.
+func (c *Conf) Copy() *koanf.Koanf {
+	return c.Current().Copy()
+}
+
+// Marshal takes a Parser implementation and marshals the config map into bytes.
+func (c *Conf) Marshal(p koanf.Parser) ([]byte, error) {
+	return c.Current().Marshal(p)
+}
+
+// UnmarshalWithConf is like Unmarshal but takes configuration params in UnmarshalConf.
+func (c *Conf) UnmarshalWithConf(path string, o interface{}, uc koanf.UnmarshalConf) error {
+	return c.Current().UnmarshalWithConf(path, o, uc)
+}
+
+// Get returns the raw, uncast interface{} value of a given key path in the config map.
+func (c *Conf) Get(path string) interface{} {
+	return c.Current().Get(path)
+}
+
+// Slices returns a list of Koanf instances constructed out of a []map[string]interface{} interface at the given path.
+func (c *Conf) Slices(path string) []*koanf.Koanf {
+	return c.Current().Slices(path)
+}
+
+// Exists returns true if the given key path exists in the conf map.
+func (c *Conf) Exists(path string) bool {
+	return c.Current().Exists(path)
+}
+
+// MapKeys returns a sorted string list of keys in a map addressed by the given path.
+func (c *Conf) MapKeys(path string) []string {
+	return c.Current().MapKeys(path)
+}
+
+// Delim returns delimiter in used by this instance of Koanf.
+func (c *Conf) Delim() string {
+	return c.Current().Delim()
+}
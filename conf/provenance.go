@@ -0,0 +1,143 @@
+package conf
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/confmap"
+)
+
+// Source describes where a configuration value was last set.
+type Source struct {
+	// Parser is the kind of parser that set the value such as map, file, bytes, struct, env or flag.
+	Parser string
+	// Name is the file path, environment variable or flag name that set the value if there is one.
+	Name string
+}
+
+func (s Source) String() string {
+	if s.Name == "" {
+		return s.Parser
+	}
+	return s.Parser + " " + s.Name
+}
+
+// Load loads configuration from a koanf provider and parser. It wraps koanf.Koanf.Load
+// to record provenance. Use the Parse functions to record a more specific source.
+func (c *Conf) Load(p koanf.Provider, pa koanf.Parser, opts ...koanf.Option) error {
+	return c.load(Source{Parser: fmt.Sprintf("%T", p)}, nil, p, pa, opts...)
+}
+
+// load loads configuration and records src as the source of every key it sets.
+// names can be used to override the source name for specific keys and their children.
+// It's read after the provider is read so it can be filled in by provider callbacks.
+func (c *Conf) load(src Source, names map[string]string, p koanf.Provider, pa koanf.Parser, opts ...koanf.Option) error {
+
+	// Load into a temporary instance first so we know what keys it sets.
+	k := koanf.New(c.Delimiter)
+	if err := k.Load(p, pa); err != nil {
+		return err
+	}
+
+	// Move any deprecated keys to their replacements.
+	c.mu.RLock()
+	aliases := make(map[string]string, len(c.aliases))
+	for oldKey, newKey := range c.aliases {
+		aliases[oldKey] = newKey
+	}
+	c.mu.RUnlock()
+	if names == nil {
+		names = make(map[string]string)
+	}
+	moved, err := applyAliases(k, names, aliases, src)
+	if err != nil {
+		return err
+	}
+	if err := c.checkAliases(k, aliases, moved, src); err != nil {
+		return err
+	}
+	if err := c.update(func(ck *koanf.Koanf) error {
+		return ck.Load(confmap.Provider(k.Raw(), ""), nil, opts...)
+	}); err != nil {
+		return err
+	}
+	c.resetTenantCache()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loads++
+	for _, newKey := range aliases {
+		if oldKey, ok := moved[newKey]; ok {
+			c.aliased[newKey] = oldKey
+		} else if k.Exists(newKey) {
+			delete(c.aliased, newKey)
+		}
+	}
+	for key := range k.All() {
+		c.loadOrder[key] = c.loads
+		delete(c.resolved, key) // A new value may need resolving again
+		s := src
+		// Use the name of the key or its nearest parent.
+		for parent := key; ; {
+			if name, ok := names[parent]; ok {
+				s.Name = name
+				break
+			}
+			i := strings.LastIndex(parent, c.Delimiter)
+			if i < 0 {
+				break
+			}
+			parent = parent[:i]
+		}
+		c.provenance[key] = s
+	}
+	return nil
+}
+
+// Explain returns the source that last set key. It returns false if the key was not set
+// by any known source.
+func (c *Conf) Explain(key string) (Source, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s, ok := c.provenance[key]
+	return s, ok
+}
+
+// Provenance returns the source of every key that has been set.
+func (c *Conf) Provenance() map[string]Source {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make(map[string]Source, len(c.provenance))
+	for key, s := range c.provenance {
+		out[key] = s
+	}
+	return out
+}
+
+// SprintProvenance returns a key -> value (source) string representation of the config
+// map with any secret values redacted.
+func (c *Conf) SprintProvenance() string {
+	var b bytes.Buffer
+	all := c.All()
+	provenance := c.Provenance()
+	keys := make([]string, 0, len(all))
+	for key := range all {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := all[key]
+		if c.IsSecret(key) {
+			value = Redacted
+		}
+		src, ok := provenance[key]
+		if !ok {
+			src = Source{Parser: "unknown"}
+		}
+		fmt.Fprintf(&b, "%s -> %v (%s)\n", key, value, src)
+	}
+	return b.String()
+}
@@ -0,0 +1,495 @@
+package conf
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// JSONSchemaVersion is the JSON Schema draft used by generated schemas.
+const JSONSchemaVersion = "https://json-schema.org/draft/2020-12/schema"
+
+const (
+	durationPattern = `^(-?([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+|0)$`
+	decimalPattern  = `^-?[0-9]+(\.[0-9]+)?$`
+	ipNetPattern    = `^[0-9a-fA-F:.]+/[0-9]+$`
+	fileModePattern = `^(0[oO]?)?[0-7]+$`
+	byteSizePattern = `^\s*[0-9]+(\.[0-9]+)?\s*([kKmMgGtTpP][iI]?)?[bB]?\s*$`
+	levelPattern    = `^([dD][eE][bB][uU][gG]|[iI][nN][fF][oO]|[wW][aA][rR][nN]([iI][nN][gG])?|[eE][rR][rR]([oO][rR])?)([+-][0-9]+)?$`
+)
+
+// SchemaTypes is the JSON Schema type keyword. It marshals to a single string when
+// there is only one type.
+type SchemaTypes []string
+
+func (t SchemaTypes) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *SchemaTypes) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*t = SchemaTypes{s}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(t))
+}
+
+// Schema is the subset of JSON Schema used to describe configuration.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 SchemaTypes        `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+// JSONSchema generates a JSON Schema for the struct type of v. Keys are named using tag
+// (usually "conf"). Descriptions come from help tags, defaults from default tags and
+// validate tags are translated where possible. Types handled by DefaultDecodeHooks like
+// durations, IPs, decimal.Decimal and slog.Level are described as the strings they are
+// configured with. Fields with validate:"required" are only required if they have no default.
+func JSONSchema(v interface{}, tag string) *Schema {
+	s := typeSchema(reflect.TypeOf(v), tag)
+	s.Schema = JSONSchemaVersion
+	return s
+}
+
+// typeSchema returns the schema for a type.
+func typeSchema(t reflect.Type, tag string) *Schema {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// Special types first
+	switch t {
+	case reflect.TypeOf(time.Duration(0)):
+		return &Schema{Type: SchemaTypes{"string", "integer"}, Pattern: durationPattern}
+	case reflect.TypeOf(time.Time{}):
+		return &Schema{Type: SchemaTypes{"string"}, Format: "date-time"}
+	case reflect.TypeOf(net.IP{}):
+		return &Schema{Type: SchemaTypes{"string"}, AnyOf: []*Schema{{Format: "ipv4"}, {Format: "ipv6"}}}
+	case reflect.TypeOf(net.IPNet{}):
+		return &Schema{Type: SchemaTypes{"string"}, Pattern: ipNetPattern}
+	case reflect.TypeOf(decimal.Decimal{}):
+		return &Schema{Type: SchemaTypes{"string", "number"}, Pattern: decimalPattern}
+	case reflect.TypeOf(slog.LevelInfo):
+		return &Schema{Type: SchemaTypes{"string"}, Pattern: levelPattern}
+	case reflect.TypeOf(url.URL{}):
+		return &Schema{Type: SchemaTypes{"string"}, Format: "uri"}
+	case reflect.TypeOf(regexp.Regexp{}):
+		return &Schema{Type: SchemaTypes{"string"}, Format: "regex"}
+	case reflect.TypeOf(time.Location{}):
+		return &Schema{Type: SchemaTypes{"string"}}
+	case reflect.TypeOf(fs.FileMode(0)):
+		return &Schema{Type: SchemaTypes{"string", "integer"}, Pattern: fileModePattern}
+	case reflect.TypeOf(ByteSize(0)):
+		return &Schema{Type: SchemaTypes{"string", "integer"}, Pattern: byteSizePattern}
+	case reflect.TypeOf(x509.CertPool{}):
+		return &Schema{Type: SchemaTypes{"string", "array"}, Items: &Schema{Type: SchemaTypes{"string"}}}
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return &Schema{Type: SchemaTypes{"string"}}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: SchemaTypes{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: SchemaTypes{"integer"}}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		min := 0.0
+		return &Schema{Type: SchemaTypes{"integer"}, Minimum: &min}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: SchemaTypes{"number"}}
+	case reflect.String:
+		return &Schema{Type: SchemaTypes{"string"}}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: SchemaTypes{"array"}, Items: typeSchema(t.Elem(), tag)}
+	case reflect.Map:
+		return &Schema{Type: SchemaTypes{"object"}, AdditionalProperties: typeSchema(t.Elem(), tag)}
+	case reflect.Struct:
+		s := &Schema{Type: SchemaTypes{"object"}, Properties: make(map[string]*Schema)}
+		structSchema(s, t, tag)
+		return s
+	}
+
+	// Anything goes
+	return &Schema{}
+}
+
+// structSchema adds the fields of struct type t to the object schema s.
+func structSchema(s *Schema, t reflect.Type, tag string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		switch field.Type.Kind() {
+		case reflect.Interface, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+			continue
+		}
+		name, squash, skip := fieldKey(field, tag)
+		if skip {
+			continue
+		}
+		if squash {
+			ft := field.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				structSchema(s, ft, tag)
+				continue
+			}
+		}
+
+		fs := typeSchema(field.Type, tag)
+		fs.Description = field.Tag.Get(HelpTag)
+		if def, ok := field.Tag.Lookup(DefaultsTag); ok && def != "" {
+			fs.Default = schemaDefault(field.Type, def)
+		}
+		if validateSchema(fs, field.Type, field.Tag.Get(ValidateTag)) && fs.Default == nil {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = fs
+	}
+}
+
+// schemaDefault converts a default tag value into the type it represents.
+func schemaDefault(t reflect.Type, def string) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Duration(0)) || isTextType(t) {
+		return def
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(def); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if i, err := strconv.ParseInt(def, 0, 64); err == nil {
+			return i
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(def, 64); err == nil {
+			return f
+		}
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		var out interface{}
+		if err := json.Unmarshal([]byte(def), &out); err == nil {
+			return out
+		}
+	}
+	return def
+}
+
+// validateSchema applies validate tag rules to the schema where possible. It returns
+// true if the field is required.
+func validateSchema(s *Schema, t reflect.Type, rules string) bool {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var required bool
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "required":
+			required = true
+		case "min", "max":
+			if t == reflect.TypeOf(time.Duration(0)) {
+				continue
+			}
+			f, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				continue
+			}
+			n := int(f)
+			switch t.Kind() {
+			case reflect.String:
+				if name == "min" {
+					s.MinLength = &n
+				} else {
+					s.MaxLength = &n
+				}
+			case reflect.Slice, reflect.Array:
+				if name == "min" {
+					s.MinItems = &n
+				} else {
+					s.MaxItems = &n
+				}
+			default:
+				if name == "min" {
+					s.Minimum = &f
+				} else {
+					s.Maximum = &f
+				}
+			}
+		case "oneof":
+			s.Enum = nil
+			for _, option := range strings.Fields(arg) {
+				s.Enum = append(s.Enum, option)
+			}
+		case "regex":
+			s.Pattern = arg
+		case "url":
+			s.Format = "uri"
+		}
+	}
+	return required
+}
+
+// Validate checks value against the schema using the same weak typing as Unmarshal, so
+// strings that parse as numbers or booleans (like those from environment variables) are
+// accepted. Every failure is returned as ValidationErrors with keys prefixed with path.
+func (s *Schema) Validate(value interface{}, path string) error {
+	var errs ValidationErrors
+	s.validate(value, path, DefaultDelimiter, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateSchema checks the configuration at path against the schema.
+// See Schema.Validate for more information.
+func (c *Conf) ValidateSchema(s *Schema, path string) error {
+	var errs ValidationErrors
+	s.validate(c.Get(path), path, c.Delimiter, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validate checks value against the schema appending any failures to errs.
+func (s *Schema) validate(value interface{}, key string, delimiter string, errs *ValidationErrors) {
+
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, &ValidationError{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if value == nil {
+		return
+	}
+
+	if len(s.Type) > 0 && !s.Type.matches(value) {
+		fail("must be of type %s", strings.Join(s.Type, " or "))
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, option := range s.Enum {
+			if fmt.Sprint(option) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			options := make([]string, len(s.Enum))
+			for i, option := range s.Enum {
+				options[i] = fmt.Sprint(option)
+			}
+			fail("must be one of %s", strings.Join(options, ", "))
+		}
+	}
+
+	if len(s.AnyOf) > 0 {
+		matched := false
+		for _, sub := range s.AnyOf {
+			var subErrs ValidationErrors
+			sub.validate(value, key, delimiter, &subErrs)
+			if len(subErrs) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("does not match any allowed format")
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		if s.Pattern != "" {
+			if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(v) {
+				fail("must match %s", s.Pattern)
+			}
+		}
+		if s.Format != "" && !validFormat(s.Format, v) {
+			fail("must be a valid %s", s.Format)
+		}
+		if s.MinLength != nil && len(v) < *s.MinLength {
+			fail("must have length at least %d", *s.MinLength)
+		}
+		if s.MaxLength != nil && len(v) > *s.MaxLength {
+			fail("must have length at most %d", *s.MaxLength)
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, &ValidationError{Key: joinKeyDelimiter(key, name, delimiter), Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if prop, ok := s.Properties[name]; ok {
+				prop.validate(v[name], joinKeyDelimiter(key, name, delimiter), delimiter, errs)
+			} else if s.AdditionalProperties != nil {
+				s.AdditionalProperties.validate(v[name], joinKeyDelimiter(key, name, delimiter), delimiter, errs)
+			}
+		}
+		return
+	}
+
+	if items := reflect.ValueOf(value); items.Kind() == reflect.Slice {
+		if s.MinItems != nil && items.Len() < *s.MinItems {
+			fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && items.Len() > *s.MaxItems {
+			fail("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i := 0; i < items.Len(); i++ {
+				s.Items.validate(items.Index(i).Interface(), joinKeyDelimiter(key, strconv.Itoa(i), delimiter), delimiter, errs)
+			}
+		}
+		return
+	}
+
+	if s.Minimum != nil || s.Maximum != nil {
+		if f, ok := schemaNumber(value); ok {
+			if s.Minimum != nil && f < *s.Minimum {
+				fail("must be at least %v", *s.Minimum)
+			}
+			if s.Maximum != nil && f > *s.Maximum {
+				fail("must be at most %v", *s.Maximum)
+			}
+		}
+	}
+}
+
+// matches returns true if the value matches any of the types.
+func (t SchemaTypes) matches(value interface{}) bool {
+	for _, typ := range t {
+		switch typ {
+		case "string": // Numbers and booleans are weakly decoded into strings
+			switch value.(type) {
+			case string, bool:
+				return true
+			}
+			if _, ok := schemaNumber(value); ok {
+				return true
+			}
+		case "boolean":
+			switch v := value.(type) {
+			case bool:
+				return true
+			case string:
+				if _, err := strconv.ParseBool(v); err == nil {
+					return true
+				}
+			}
+		case "integer":
+			if f, ok := schemaNumber(value); ok && f == float64(int64(f)) {
+				return true
+			}
+		case "number":
+			if _, ok := schemaNumber(value); ok {
+				return true
+			}
+		case "object":
+			if _, ok := value.(map[string]interface{}); ok {
+				return true
+			}
+		case "array": // Single values are weakly decoded into slices
+			if _, ok := value.(map[string]interface{}); !ok {
+				return true
+			}
+		case "null":
+			if value == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// schemaNumber converts numbers and numeric strings to a float64.
+func schemaNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	case bool:
+		return 0, false
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// validFormat checks the string formats used by generated schemas.
+func validFormat(format string, s string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	case "ipv4":
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil
+	case "ipv6":
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() == nil
+	case "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	}
+	return true
+}
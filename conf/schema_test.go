@@ -0,0 +1,31 @@
+package conf
+
+import (
+	"log/slog"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/snowzach/golib/log"
+)
+
+func TestSchemaLevelPattern(t *testing.T) {
+	pattern := regexp.MustCompile(typeSchema(reflect.TypeOf(slog.LevelInfo), DefaultTag).Pattern)
+	for _, level := range []string{"debug", "INFO", "Warn", "warning", "ERR", "Error", "info+2", "DEBUG-4", "error+10", "nope", "info+", "info2", "+2", ""} {
+		_, err := log.ParseLogLevel(level)
+		if matched := pattern.MatchString(level); matched != (err == nil) {
+			t.Errorf("%q: schema matches %v, ParseLogLevel error %v", level, matched, err)
+		}
+	}
+}
+
+func TestSchemaValidateLevel(t *testing.T) {
+	s := JSONSchema(&struct {
+		Level slog.Level `conf:"level"`
+	}{}, DefaultTag)
+	for level, valid := range map[string]bool{"Info": true, "WARN+2": true, "verbose": false} {
+		if err := s.Validate(map[string]interface{}{"level": level}, ""); (err == nil) != valid {
+			t.Errorf("%s: error %v, want valid %v", level, err, valid)
+		}
+	}
+}
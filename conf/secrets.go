@@ -0,0 +1,277 @@
+package conf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	// Redacted is what secret values are replaced with when printed.
+	Redacted = "[REDACTED]"
+	// SecretTag is the struct tag used to mark a configuration field secret.
+	SecretTag = "secret"
+)
+
+// DefaultSecretPatterns are common patterns for keys holding secrets. New Conf instances
+// redact keys matching them. See MarkSecretPattern.
+var DefaultSecretPatterns = []string{"*password*", "*passwd*", "*secret*", "*token*", "*apikey*", "*api_key*", "*private_key*", "*credential*"}
+
+// Resolver resolves a secret reference into its value. It is passed everything
+// after the scheme, so "file:///run/secrets/db" is passed "/run/secrets/db".
+type Resolver func(ref string) (string, error)
+
+// RegisterResolver registers a Resolver for values beginning with scheme:// such as
+// "file", "env" or "exec". Once any resolver is registered, Parse will resolve matching
+// string values after all parsers have run and mark those keys as secret.
+func (c *Conf) RegisterResolver(scheme string, r Resolver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resolvers[scheme] = r
+}
+
+// RegisterDefaultResolvers registers the file and env resolvers. The exec resolver is
+// not registered by default as it allows configuration to run commands.
+func (c *Conf) RegisterDefaultResolvers() {
+	c.RegisterResolver("file", FileResolver)
+	c.RegisterResolver("env", EnvResolver)
+}
+
+// ResolveSecrets replaces any string value that references a registered resolver
+// scheme with the resolved value and marks the key as secret. Values that were already
+// resolved or decrypted are left alone. Parse calls this for you.
+func (c *Conf) ResolveSecrets() error {
+
+	c.mu.RLock()
+	resolvers := make(map[string]Resolver, len(c.resolvers))
+	for scheme, r := range c.resolvers {
+		resolvers[scheme] = r
+	}
+	done := make(map[string]struct{}, len(c.resolved))
+	for key := range c.resolved {
+		done[key] = struct{}{}
+	}
+	c.mu.RUnlock()
+	if len(resolvers) == 0 {
+		return nil
+	}
+
+	for key, value := range c.All() {
+		s, ok := value.(string)
+		if _, isDone := done[key]; !ok || isDone {
+			continue
+		}
+		scheme, ref, found := strings.Cut(s, "://")
+		if !found {
+			continue
+		}
+		r, ok := resolvers[scheme]
+		if !ok {
+			continue
+		}
+		resolved, err := r(ref)
+		if err != nil {
+			return fmt.Errorf("could not resolve secret %s: %w", key, err)
+		}
+		if err := c.Set(key, resolved); err != nil {
+			return fmt.Errorf("could not set secret %s: %w", key, err)
+		}
+		c.markResolved(key)
+	}
+
+	return nil
+}
+
+// markResolved marks keys set by a resolver or decryption as secret and excludes them
+// from interpolation so their values are used as is.
+func (c *Conf) markResolved(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		c.secrets[key] = struct{}{}
+		c.resolved[key] = struct{}{}
+	}
+}
+
+// MarkSecret marks keys as secret so they are redacted when printed. They stay secret
+// across Reload.
+func (c *Conf) MarkSecret(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		c.secrets[key] = struct{}{}
+		c.marked[key] = struct{}{}
+	}
+}
+
+// MarkSecretPattern marks every key matching any of the patterns as secret. Patterns
+// use path.Match syntax and are matched case insensitively against the full key, so
+// *password* matches both password and database.Password. Like MarkSecret, patterns
+// are kept across Reload.
+func (c *Conf) MarkSecretPattern(patterns ...string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid secret pattern %s: %w", pattern, err)
+		}
+	}
+	lower := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		lower[i] = strings.ToLower(pattern)
+	}
+	c.addSecretPatterns(lower)
+	return nil
+}
+
+// MarkSecretFields marks the keys of fields in the struct v tagged secret:"true" or
+// of type Secret as secret. Keys are named using tag (usually "conf") and prefixed with
+// path. Like MarkSecretPattern, these keys are kept across Reload. Unmarshal calls this
+// for you when unmarshaling into a struct.
+func (c *Conf) MarkSecretFields(v interface{}, path string, tag string) {
+	var keys []string
+	for _, f := range fields(v, path, tag, c.Delimiter) {
+		if f.Type == reflect.TypeOf(Secret("")) || f.StructField.Tag.Get(SecretTag) == "true" {
+			keys = append(keys, secretPatternEscaper.Replace(strings.ToLower(f.Key)))
+		}
+	}
+	c.addSecretPatterns(keys)
+}
+
+// addSecretPatterns adds any patterns that have not already been added.
+func (c *Conf) addSecretPatterns(patterns []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, pattern := range patterns {
+		if !slices.Contains(c.secretPatterns, pattern) {
+			c.secretPatterns = append(c.secretPatterns, pattern)
+		}
+	}
+}
+
+// secretPatternEscaper escapes the path.Match meta characters in a key.
+var secretPatternEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`)
+
+// IsSecret returns true if the key, or any parent of the key, has been marked secret
+// or matches a secret pattern.
+func (c *Conf) IsSecret(key string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for {
+		if _, ok := c.secrets[key]; ok {
+			return true
+		}
+		for _, pattern := range c.secretPatterns {
+			if matched, _ := path.Match(pattern, strings.ToLower(key)); matched {
+				return true
+			}
+		}
+		i := strings.LastIndex(key, c.Delimiter)
+		if i < 0 {
+			return false
+		}
+		key = key[:i]
+	}
+}
+
+// SecretKeys returns the sorted list of keys marked secret.
+func (c *Conf) SecretKeys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	keys := make([]string, 0, len(c.secrets))
+	for key := range c.secrets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Sprint returns a key -> value string representation of the config map
+// with any secret values redacted.
+func (c *Conf) Sprint() string {
+	var b bytes.Buffer
+	all := c.All()
+	for _, key := range c.Keys() {
+		value, ok := all[key]
+		if !ok {
+			continue
+		}
+		if c.IsSecret(key) {
+			value = Redacted
+		}
+		fmt.Fprintf(&b, "%s -> %v\n", key, value)
+	}
+	return b.String()
+}
+
+// Print prints a key -> value string representation of the config map
+// with any secret values redacted.
+func (c *Conf) Print() {
+	fmt.Print(c.Sprint())
+}
+
+// FileResolver reads the secret from the file path. Trailing newlines are removed.
+func FileResolver(ref string) (string, error) {
+	b, err := os.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// EnvResolver reads the secret from the environment variable. It's an error if it's not set.
+func EnvResolver(ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", ref)
+	}
+	return value, nil
+}
+
+// ExecResolver runs the command (split on spaces) and uses its output as the secret.
+// Trailing newlines are removed.
+func ExecResolver(ref string) (string, error) {
+	args := strings.Fields(ref)
+	if len(args) == 0 {
+		return "", fmt.Errorf("no command specified")
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("command %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// Secret is a string that is redacted when printed or marshaled. Use it for struct
+// fields holding secrets and call Value to get the actual value.
+type Secret string
+
+// Value returns the secret value.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String returns the redacted value.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return Redacted
+}
+
+// GoString returns the redacted value for %#v.
+func (s Secret) GoString() string {
+	return `"` + s.String() + `"`
+}
+
+// MarshalText returns the redacted value.
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
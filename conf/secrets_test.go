@@ -0,0 +1,35 @@
+package conf
+
+import (
+	"testing"
+)
+
+func TestResolveSecretsOnce(t *testing.T) {
+	calls := 0
+	c := New()
+	c.RegisterResolver("vault", func(ref string) (string, error) {
+		calls++
+		if ref == "db" {
+			return "vault://nested", nil
+		}
+		return "resolved " + ref, nil
+	})
+	if err := c.Parse(WithMap(map[string]interface{}{"db.password": "vault://db"})); err != nil {
+		t.Fatal(err)
+	}
+	// A second Parse must not resolve the resolved value again.
+	if err := c.Parse(WithMap(map[string]interface{}{"other": 1})); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("db.password"); got != "vault://nested" || calls != 1 {
+		t.Errorf("db.password = %q after %d calls, want %q after 1", got, calls, "vault://nested")
+	}
+
+	// A new reference is resolved.
+	if err := c.Parse(WithMap(map[string]interface{}{"db.password": "vault://other"})); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("db.password"); got != "resolved other" || !c.IsSecret("db.password") {
+		t.Errorf("db.password = %q, want %q and secret", got, "resolved other")
+	}
+}
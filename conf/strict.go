@@ -0,0 +1,255 @@
+package conf
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/knadh/koanf/maps"
+)
+
+// UnusedKey is a config key that no struct field consumed.
+type UnusedKey struct {
+	Key string
+	// Suggestion is the closest valid key if there is one that is close enough.
+	Suggestion string
+	// Source is where the key was set, see Explain.
+	Source Source
+}
+
+func (e *UnusedKey) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Key + ": unknown key")
+	if e.Source.Parser != "" {
+		b.WriteString(" set by " + e.Source.String())
+	}
+	if e.Suggestion != "" {
+		b.WriteString(", did you mean " + e.Suggestion + "?")
+	}
+	return b.String()
+}
+
+// UnusedKeys is every unused key found while unmarshaling in strict mode.
+type UnusedKeys []*UnusedKey
+
+func (e UnusedKeys) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// CheckUnused returns UnusedKeys listing every config key under path that would not be
+// consumed when unmarshaling into v with tag, or nil if there are none. Each includes
+// the closest valid key by edit distance and the source that set it. Unmarshal calls
+// this for you in strict mode.
+func (c *Conf) CheckUnused(v interface{}, path string, tag string) error {
+	return c.checkUnused(v, path, tag, nil, false)
+}
+
+// checkUnused finds unused keys matching names like the decoder does with match and squash.
+func (c *Conf) checkUnused(v interface{}, path string, tag string, match func(mapKey, fieldName string) bool, squash bool) error {
+
+	if match == nil {
+		match = strings.EqualFold
+	}
+	sc := &strictChecker{
+		c:      c,
+		tag:    tag,
+		match:  match,
+		squash: squash,
+	}
+	for _, f := range fields(v, path, tag, c.Delimiter) {
+		sc.valid = append(sc.valid, f.Key)
+	}
+
+	sc.walk(reflect.TypeOf(v), c.Get(path), path)
+	if len(sc.unused) == 0 {
+		return nil
+	}
+	return sc.unused
+}
+
+// strictChecker walks config values alongside the type they decode into.
+type strictChecker struct {
+	c      *Conf
+	tag    string
+	match  func(mapKey, fieldName string) bool
+	squash bool
+	valid  []string
+	unused UnusedKeys
+}
+
+// walk records anything in value under key that type t does not consume.
+func (sc *strictChecker) walk(t reflect.Type, value interface{}, key string) {
+
+	if t == nil {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := value.(map[string]interface{})
+		if !ok || isLeafType(t) {
+			return
+		}
+		fields, remain := sc.structFields(t)
+		// Suggest the fields of this struct as well as every valid key.
+		candidates := append([]string(nil), sc.valid...)
+		for _, field := range fields {
+			candidates = append(candidates, joinKeyDelimiter(key, field.name, sc.c.Delimiter))
+		}
+		for _, mapKey := range sortedKeys(m) {
+			field, found := sc.findField(fields, mapKey)
+			if !found {
+				if !remain {
+					sc.addUnused(joinKeyDelimiter(key, mapKey, sc.c.Delimiter), m[mapKey], candidates)
+				}
+				continue
+			}
+			sc.walk(field.Type, m[mapKey], joinKeyDelimiter(key, mapKey, sc.c.Delimiter))
+		}
+	case reflect.Slice, reflect.Array:
+		if s, ok := value.([]interface{}); ok {
+			for i, elem := range s {
+				sc.walk(t.Elem(), elem, joinKeyDelimiter(key, strconv.Itoa(i), sc.c.Delimiter))
+			}
+		}
+	case reflect.Map:
+		if m, ok := value.(map[string]interface{}); ok {
+			for _, mapKey := range sortedKeys(m) {
+				sc.walk(t.Elem(), m[mapKey], joinKeyDelimiter(key, mapKey, sc.c.Delimiter))
+			}
+		}
+	}
+}
+
+// structField is a field and the name it's decoded from.
+type structField struct {
+	name string
+	reflect.StructField
+}
+
+// structFields returns the decodable fields of t including squashed embedded structs
+// and whether there is a remain field that consumes any other keys.
+func (sc *strictChecker) structFields(t reflect.Type) ([]structField, bool) {
+	var out []structField
+	var remain bool
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get(sc.tag), ",")
+		if name == "-" {
+			continue
+		}
+		squash := sc.squash && field.Anonymous
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "squash":
+				squash = true
+			case "remain":
+				remain = true
+			}
+		}
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if squash && ft.Kind() == reflect.Struct {
+			embedded, embeddedRemain := sc.structFields(ft)
+			out = append(out, embedded...)
+			remain = remain || embeddedRemain
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		out = append(out, structField{name: name, StructField: field})
+	}
+	return out, remain
+}
+
+// findField finds the field for mapKey preferring an exact match.
+func (sc *strictChecker) findField(fields []structField, mapKey string) (structField, bool) {
+	for _, field := range fields {
+		if field.name == mapKey {
+			return field, true
+		}
+	}
+	for _, field := range fields {
+		if sc.match(mapKey, field.name) {
+			return field, true
+		}
+	}
+	return structField{}, false
+}
+
+// addUnused records key and any keys nested under it as unused suggesting the closest candidates.
+func (sc *strictChecker) addUnused(key string, value interface{}, candidates []string) {
+	keys := []string{key}
+	if m, ok := value.(map[string]interface{}); ok && len(m) > 0 {
+		flat, _ := maps.Flatten(m, []string{key}, sc.c.Delimiter)
+		keys = sortedKeys(flat)
+	}
+	for _, key := range keys {
+		unused := &UnusedKey{
+			Key:        key,
+			Suggestion: closestKey(key, candidates),
+		}
+		// Keys inside slices are not tracked so use the nearest parent.
+		for parent := key; ; {
+			if src, ok := sc.c.Explain(parent); ok {
+				unused.Source = src
+				break
+			}
+			i := strings.LastIndex(parent, sc.c.Delimiter)
+			if i < 0 {
+				break
+			}
+			parent = parent[:i]
+		}
+		sc.unused = append(sc.unused, unused)
+	}
+}
+
+// closestKey returns the candidate closest to key by edit distance if it differs by
+// at most a third of its length.
+func closestKey(key string, candidates []string) string {
+	var closest string
+	best := -1
+	for _, candidate := range candidates {
+		d := editDistance(strings.ToLower(key), strings.ToLower(candidate))
+		if d*3 <= len(candidate) && (best < 0 || d < best) {
+			closest, best = candidate, d
+		}
+	}
+	return closest
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,184 @@
+package conf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/confmap"
+)
+
+// tenantLayer is a tenant's overrides merged over the base configuration at path.
+type tenantLayer struct {
+	k       *koanf.Koanf
+	path    string
+	src     Source
+	secrets []string
+	keep    bool // Set outside Parse so it's kept across Reload
+}
+
+// WithTenantDir loads tenant override layers from a directory.
+// See ParseTenantDir for more information.
+func WithTenantDir(dir string) ParserFunc {
+	return func(c *Conf) error {
+		return c.ParseTenantDir(dir)
+	}
+}
+
+// WithTenantMap loads tenant override layers from a map of tenant name to overrides.
+// See SetTenantLayer for more information.
+func WithTenantMap(tenants map[string]map[string]interface{}) ParserFunc {
+	return func(c *Conf) error {
+		for _, tenant := range sortedKeys(tenants) {
+			if err := c.SetTenantLayer(tenant, tenants[tenant]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// ParseTenantDir loads a tenant override layer from every file in dir with a registered
+// format. The tenant is named after the file, so acme.yaml holds the overrides for acme.
+// If dir is an empty string it is ignored. If it's loaded by Parse the directory is
+// watched by Watch so new tenants are added too.
+func (c *Conf) ParseTenantDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	c.addTenantDir(dir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("could not read tenant dir: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, err := formatParser(filepath.Ext(entry.Name())); err != nil {
+			continue
+		}
+		tenant := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if err := c.ParseTenantFile(tenant, filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseTenantFile loads the override layer for tenant from a file replacing any existing
+// layer. It supports any registered format. If it's loaded by Parse the file is watched
+// by Watch which reloads only this tenant's layer when it changes. Otherwise the layer
+// is kept as is across Reload.
+func (c *Conf) ParseTenantFile(tenant string, configFile string) error {
+	return c.parseTenantFile(tenant, configFile, !c.isParsing())
+}
+
+// parseTenantFile loads the override layer for tenant from a file. If keep is set
+// the layer is kept across Reload.
+func (c *Conf) parseTenantFile(tenant string, configFile string, keep bool) error {
+	c.addTenantFile(tenant, configFile)
+	b, err := os.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
+	config, decrypted, err := c.unmarshalBytes(b, filepath.Ext(configFile))
+	if err != nil {
+		return fmt.Errorf("could not parse %s: %w", configFile, err)
+	}
+	return c.setTenantLayer(tenant, "", config, Source{Parser: "tenant", Name: configFile}, decrypted, keep)
+}
+
+// SetTenantLayer sets the override layer for tenant replacing any existing layer.
+// See SetTenantLayerAt.
+func (c *Conf) SetTenantLayer(tenant string, config map[string]interface{}) error {
+	return c.SetTenantLayerAt(tenant, "", config)
+}
+
+// SetTenantLayerAt sets the override layer for tenant merged at path replacing any existing
+// layer. Only the cached configuration for tenant is discarded. See ForTenant. Layers set
+// outside Parse, such as at runtime, are kept across Reload.
+func (c *Conf) SetTenantLayerAt(tenant string, path string, config map[string]interface{}) error {
+	return c.setTenantLayer(tenant, path, config, Source{Parser: "tenant", Name: tenant}, nil, !c.isParsing())
+}
+
+// setTenantLayer sets the override layer for tenant recording src and any secret keys.
+// If keep is set the layer is kept across Reload.
+func (c *Conf) setTenantLayer(tenant string, path string, config map[string]interface{}, src Source, secrets []string, keep bool) error {
+	k := koanf.New(c.Delimiter)
+	if err := k.Load(confmap.Provider(config, c.Delimiter), nil); err != nil {
+		return fmt.Errorf("could not load tenant %s: %w", tenant, err)
+	}
+	c.tenantMu.Lock()
+	defer c.tenantMu.Unlock()
+	c.tenants[tenant] = &tenantLayer{k: k, path: path, src: src, secrets: secrets, keep: keep}
+	delete(c.tenantCache, tenant)
+	c.tenantGeneration++
+	return nil
+}
+
+// RemoveTenant removes the override layer for tenant.
+func (c *Conf) RemoveTenant(tenant string) {
+	c.tenantMu.Lock()
+	defer c.tenantMu.Unlock()
+	delete(c.tenants, tenant)
+	delete(c.tenantCache, tenant)
+	c.tenantGeneration++
+}
+
+// Tenants returns the sorted list of tenants with override layers.
+func (c *Conf) Tenants() []string {
+	c.tenantMu.Lock()
+	defer c.tenantMu.Unlock()
+	tenants := make([]string, 0, len(c.tenants))
+	for tenant := range c.tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// ForTenant returns the configuration for tenant, the base configuration with the tenant's
+// override layer merged over it using MergeAt. It's built the first time it's requested and
+// cached until the tenant's layer or the base configuration changes. A tenant without a layer
+// gets the base configuration. The result must not be modified.
+func (c *Conf) ForTenant(tenant string) *Conf {
+	c.tenantMu.Lock()
+	if tc, ok := c.tenantCache[tenant]; ok {
+		c.tenantMu.Unlock()
+		return tc
+	}
+	layer, generation := c.tenants[tenant], c.tenantGeneration
+	c.tenantMu.Unlock()
+
+	tc := c.Snapshot()
+	if layer != nil {
+		// Merging only fails with koanf's StrictMerge which is not used.
+		_ = tc.MergeAt(layer.k, layer.path)
+		for _, key := range layer.k.Keys() {
+			tc.provenance[joinKeyDelimiter(layer.path, key, c.Delimiter)] = layer.src
+		}
+		for _, key := range layer.secrets {
+			tc.secrets[joinKeyDelimiter(layer.path, key, c.Delimiter)] = struct{}{}
+		}
+	}
+
+	// Only cache it if nothing changed while it was built.
+	c.tenantMu.Lock()
+	defer c.tenantMu.Unlock()
+	if c.tenantGeneration == generation {
+		c.tenantCache[tenant] = tc
+	}
+	return tc
+}
+
+// resetTenantCache discards every cached tenant configuration.
+func (c *Conf) resetTenantCache() {
+	c.tenantMu.Lock()
+	defer c.tenantMu.Unlock()
+	c.tenantCache = make(map[string]*Conf)
+	c.tenantGeneration++
+}
@@ -0,0 +1,146 @@
+package conf
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForTenant(t *testing.T) {
+	c := New()
+	if err := c.Parse(
+		WithMap(map[string]interface{}{"x": 1, "y": 1}),
+		WithTenantMap(map[string]map[string]interface{}{"acme": {"x": 2}}),
+	); err != nil {
+		t.Fatal(err)
+	}
+	acme := c.ForTenant("acme")
+	if acme.Int("x") != 2 || acme.Int("y") != 1 {
+		t.Fatalf("acme x = %d, y = %d, want 2 and 1", acme.Int("x"), acme.Int("y"))
+	}
+	if src, _ := acme.Explain("x"); src.Parser != "tenant" {
+		t.Errorf("acme x source = %s, want tenant", src)
+	}
+	if c.ForTenant("acme") != acme {
+		t.Error("expected the cached tenant config")
+	}
+	if c.ForTenant("other").Int("x") != 1 || c.Int("x") != 1 {
+		t.Error("the base config should not be changed")
+	}
+}
+
+func TestReloadKeepsTenantLayers(t *testing.T) {
+	c := New()
+	if err := c.Parse(
+		WithMap(map[string]interface{}{"x": 1}),
+		WithTenantMap(map[string]map[string]interface{}{"acme": {"x": 2}, "globex": {"x": 3}}),
+	); err != nil {
+		t.Fatal(err)
+	}
+	// Layers set at runtime are kept, parsed layers are rebuilt.
+	if err := c.SetTenantLayer("acme", map[string]interface{}{"x": 4}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetTenantLayer("initech", map[string]interface{}{"x": 5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	for tenant, want := range map[string]int{"acme": 4, "globex": 3, "initech": 5} {
+		if got := c.ForTenant(tenant).Int("x"); got != want {
+			t.Errorf("%s x = %d, want %d", tenant, got, want)
+		}
+	}
+}
+
+func TestTenantDirReload(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "acme.yaml")
+	if err := os.WriteFile(file, []byte("x: 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New()
+	if err := c.Parse(WithMap(map[string]interface{}{"x": 1}), WithTenantDir(dir)); err != nil {
+		t.Fatal(err)
+	}
+	if c.ForTenant("acme").Int("x") != 2 {
+		t.Fatal("acme x should be 2")
+	}
+
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if tenants := c.Tenants(); len(tenants) != 0 {
+		t.Fatalf("tenants = %v, want none", tenants)
+	}
+	if c.ForTenant("acme").Int("x") != 1 {
+		t.Fatal("acme x should be 1 once its file is removed")
+	}
+}
+
+func TestTenantWatch(t *testing.T) {
+	dir := t.TempDir()
+	for file, data := range map[string]string{"acme.yaml": "x: 2\n", "other.yaml": "x: 3\n"} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := New()
+	if err := c.Parse(WithMap(map[string]interface{}{"x": 1}), WithTenantDir(dir)); err != nil {
+		t.Fatal(err)
+	}
+	var reloaded atomic.Bool
+	c.Subscribe("", func(string, interface{}, interface{}) { reloaded.Store(true) })
+	other := c.ForTenant("other")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := c.Watch(ctx, func(err error) { t.Error(err) }); err != nil {
+		t.Fatal(err)
+	}
+
+	// Update a tenant
+	if err := os.WriteFile(filepath.Join(dir, "acme.yaml"), []byte("x: 4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return c.ForTenant("acme").Int("x") == 4 })
+
+	// Add a tenant
+	if err := os.WriteFile(filepath.Join(dir, "new.yaml"), []byte("x: 5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return c.ForTenant("new").Int("x") == 5 })
+
+	// Remove a tenant
+	if err := os.Remove(filepath.Join(dir, "acme.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return c.ForTenant("acme").Int("x") == 1 })
+
+	if c.ForTenant("other") != other {
+		t.Error("other tenant's cached config should be kept")
+	}
+	if reloaded.Load() {
+		t.Error("tenant changes should not reload the config")
+	}
+}
+
+// waitFor waits for f to return true.
+func waitFor(t *testing.T, f func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if f() {
+			return
+		}
+	}
+	t.Fatal("timed out")
+}
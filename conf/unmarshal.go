@@ -26,6 +26,12 @@ type UnmarshalConf struct {
 	StructEnvironment bool
 	// If you wish to look for an environment variable with a prefix, you can set it here.
 	StructEnvironmentPrefix string
+	// If unmarshaling to a struct it will check the validate tags on the struct after
+	// decoding and return every failure as ValidationErrors. See Validate.
+	Validate bool
+	// Strict returns UnusedKeys listing every key under Path that no field consumed
+	// after decoding. It is ignored with FlatPaths. See CheckUnused.
+	Strict bool
 	// Decoder config is the github.com/mitchellh/mapstructure.DecoderConfig used to umarshal
 	// configuration into data structures.
 	DecoderConfig *mapstructure.DecoderConfig
@@ -66,14 +72,16 @@ func (c *Conf) Unmarshal(dest interface{}, unmarshalConfig UnmarshalConf) error
 				return fmt.Errorf("could not parse environment struct for config: %w", err)
 			}
 
-			// Create a new config and merge in the struct config where it belongs at the path.
-			cc = New()
+			// Copy the config keeping where each key came from and merge in the struct config
+			// where it belongs at the path.
+			current := c.Current()
+			cc = c.Snapshot()
 			if err := cc.MergeAt(structConfig.Koanf, unmarshalConfig.Path); err != nil {
 				return fmt.Errorf("could not merge environment struct for config: %w", err)
 			}
 
 			// Finally merge in the original passed in config to this copy.
-			if err := cc.Merge(c.Koanf); err != nil {
+			if err := cc.Merge(current); err != nil {
 				return fmt.Errorf("could not merge config: %w", err)
 			}
 
@@ -83,6 +91,21 @@ func (c *Conf) Unmarshal(dest interface{}, unmarshalConfig UnmarshalConf) error
 				return fmt.Errorf("could not reload env: %w", err)
 			}
 		}
+
+		// Move any deprecated keys that are still set to the fields replacing them on a copy.
+		// Conflicts are handled as if they were registered with RegisterAliases.
+		aliases := deprecatedAliases(dest, unmarshalConfig.Path, unmarshalConfig.DecoderConfig.TagName, c.Delimiter)
+		for oldKey := range aliases {
+			if cc.Exists(oldKey) {
+				if cc == c {
+					cc = c.Snapshot()
+				}
+				if err := cc.applyLoadedAliases(aliases); err != nil {
+					return err
+				}
+				break
+			}
+		}
 	}
 
 	// Get the source map
@@ -100,7 +123,29 @@ func (c *Conf) Unmarshal(dest interface{}, unmarshalConfig UnmarshalConf) error
 	if err != nil {
 		return fmt.Errorf("could not create decoder: %w", err)
 	}
-	return decoder.Decode(source)
+	if err := decoder.Decode(source); err != nil {
+		return err
+	}
+
+	// Mark any secret fields so they are redacted
+	if !unmarshalConfig.FlatPaths && reflect.TypeOf(dest).Kind() == reflect.Ptr && reflect.TypeOf(dest).Elem().Kind() == reflect.Struct {
+		c.MarkSecretFields(dest, unmarshalConfig.Path, unmarshalConfig.DecoderConfig.TagName)
+	}
+
+	// Check for unused keys
+	if unmarshalConfig.Strict && !unmarshalConfig.FlatPaths {
+		dc := unmarshalConfig.DecoderConfig
+		if err := cc.checkUnused(dest, unmarshalConfig.Path, dc.TagName, dc.MatchName, dc.Squash); err != nil {
+			return err
+		}
+	}
+
+	// Validate the result
+	if unmarshalConfig.Validate {
+		return validate(dest, unmarshalConfig.Path, unmarshalConfig.DecoderConfig.TagName, c.Delimiter)
+	}
+
+	return nil
 
 }
 
@@ -159,6 +204,20 @@ func WithStructEnvironment(b bool) UnmarshalOption {
 	}
 }
 
+// WithValidate enables checking validate tags after unmarshaling. See UnmarshalConf.
+func WithValidate(b bool) UnmarshalOption {
+	return func(c *UnmarshalConf) {
+		c.Validate = b
+	}
+}
+
+// WithStrict enables returning any keys not consumed when unmarshaling. See UnmarshalConf.
+func WithStrict(b bool) UnmarshalOption {
+	return func(c *UnmarshalConf) {
+		c.Strict = b
+	}
+}
+
 // DecoderConfig is the decoder config used to decode into the struct.
 func WithDecoderOpts(opts ...DecodeOption) UnmarshalOption {
 	return func(c *UnmarshalConf) {
@@ -0,0 +1,255 @@
+package conf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+)
+
+// DefaultURLClient is the http client used to fetch configuration from a URL.
+var DefaultURLClient = &http.Client{Timeout: 30 * time.Second}
+
+// URLSource fetches configuration from an HTTP endpoint. It remembers the ETag of the
+// last response so repeat fetches are cheap conditional requests.
+type URLSource struct {
+	url      string
+	client   *http.Client
+	format   string
+	header   http.Header
+	username string
+	password string
+
+	mu          sync.Mutex
+	etag        string
+	body        []byte
+	contentType string
+}
+
+// URLOption configures a URLSource.
+type URLOption func(s *URLSource)
+
+// WithURLClient sets the http client used to fetch configuration.
+func WithURLClient(client *http.Client) URLOption {
+	return func(s *URLSource) {
+		s.client = client
+	}
+}
+
+// WithURLFormat sets the format of the configuration rather than detecting it from
+// the Content-Type header or extension of the URL.
+func WithURLFormat(format string) URLOption {
+	return func(s *URLSource) {
+		s.format = format
+	}
+}
+
+// WithURLHeader sets a header sent when fetching configuration.
+func WithURLHeader(key, value string) URLOption {
+	return func(s *URLSource) {
+		s.header.Set(key, value)
+	}
+}
+
+// WithURLBearerToken sets a bearer token used to fetch configuration.
+func WithURLBearerToken(token string) URLOption {
+	return func(s *URLSource) {
+		s.header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// WithURLBasicAuth sets basic auth credentials used to fetch configuration.
+func WithURLBasicAuth(username, password string) URLOption {
+	return func(s *URLSource) {
+		s.username = username
+		s.password = password
+	}
+}
+
+// NewURLSource returns a new URLSource for the URL.
+func NewURLSource(rawURL string, opts ...URLOption) *URLSource {
+	s := &URLSource{
+		url:    rawURL,
+		client: DefaultURLClient,
+		header: make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Fetch requests the configuration using If-None-Match with the last ETag seen.
+// It returns true if the configuration changed since the last fetch.
+func (s *URLSource) Fetch(ctx context.Context) (bool, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return false, fmt.Errorf("could not create request for %s: %w", s.url, err)
+	}
+	for key, values := range s.header {
+		req.Header[key] = values
+	}
+	if s.username != "" || s.password != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+	if s.etag != "" && s.body != nil {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("could not fetch %s: %w", s.url, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return false, nil
+	case http.StatusOK:
+	default:
+		return false, fmt.Errorf("could not fetch %s: %s", s.url, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("could not read %s: %w", s.url, err)
+	}
+
+	changed := s.body == nil || string(body) != string(s.body)
+	s.body = body
+	s.etag = resp.Header.Get("ETag")
+	s.contentType = resp.Header.Get("Content-Type")
+	return changed, nil
+}
+
+// ReadBytes returns the configuration from the last fetch. It implements koanf.Provider.
+func (s *URLSource) ReadBytes() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.body == nil {
+		return nil, fmt.Errorf("%s has not been fetched", s.url)
+	}
+	return s.body, nil
+}
+
+// Read is not supported by URLSource. It implements koanf.Provider.
+func (s *URLSource) Read() (map[string]interface{}, error) {
+	return nil, errors.New("url source does not support this method")
+}
+
+// Format returns the configuration format. If it was not set with WithURLFormat
+// it is detected from the Content-Type of the last fetch or the URL extension.
+func (s *URLSource) Format() string {
+	if s.format != "" {
+		return s.format
+	}
+	s.mu.Lock()
+	contentType := s.contentType
+	s.mu.Unlock()
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch mediaType {
+		case "application/json", "text/json":
+			return "json"
+		case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+			return "yaml"
+		case "application/toml", "text/toml", "application/x-toml":
+			return "toml"
+		}
+	}
+	if u, err := url.Parse(s.url); err == nil {
+		return path.Ext(u.Path)
+	}
+	return ""
+}
+
+// WithURL parses configuration from an HTTP endpoint.
+// See ParseURL for more information.
+func WithURL(rawURL string, opts ...URLOption) ParserFunc {
+	s := NewURLSource(rawURL, opts...)
+	return func(c *Conf) error {
+		return c.ParseURLSource(s)
+	}
+}
+
+// ParseURL fetches configuration from an HTTP endpoint. The format is detected from the
+// Content-Type header or the URL extension unless set with WithURLFormat.
+func (c *Conf) ParseURL(rawURL string, opts ...URLOption) error {
+	return c.ParseURLSource(NewURLSource(rawURL, opts...))
+}
+
+// ParseURLSource fetches and parses configuration from a URLSource. If the source has
+// been fetched before a conditional request is made and the previous configuration is
+// used if it has not changed. If it's loaded by Parse it's polled by PollURLs.
+func (c *Conf) ParseURLSource(s *URLSource) error {
+	if _, err := s.Fetch(context.Background()); err != nil {
+		return err
+	}
+	c.addURLSource(s)
+	return c.parseProvider(Source{Parser: "url", Name: s.url}, s, s.Format())
+}
+
+// PollURLs fetches every URLSource loaded by Parse, such as with WithURL, every interval and calls
+// Reload when one of them has changed. It returns immediately and stops polling when ctx
+// is done. Any errors while polling or reloading are passed to errFunc if it is not nil.
+func (c *Conf) PollURLs(ctx context.Context, interval time.Duration, errFunc func(error)) {
+
+	if errFunc == nil {
+		errFunc = func(error) {}
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			c.mu.RLock()
+			sources := append([]*URLSource(nil), c.urls...)
+			c.mu.RUnlock()
+
+			var changed bool
+			for _, s := range sources {
+				sourceChanged, err := s.Fetch(ctx)
+				if err != nil {
+					errFunc(err)
+					continue
+				}
+				changed = changed || sourceChanged
+			}
+			if changed {
+				if err := c.Reload(); err != nil {
+					errFunc(err)
+				}
+			}
+		}
+	}()
+}
+
+// addURLSource records a URLSource that has been loaded by Parse so it can be polled.
+func (c *Conf) addURLSource(s *URLSource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.parsing == 0 {
+		return
+	}
+	for _, existing := range c.urls {
+		if existing == s {
+			return
+		}
+	}
+	c.urls = append(c.urls, s)
+}
@@ -0,0 +1,137 @@
+package conf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestURLSourceETag(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		body        = `{"a": 1}`
+		etag        = `"1"`
+		ifNoneMatch []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		ifNoneMatch = append(ifNoneMatch, r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	s := NewURLSource(server.URL + "/config")
+	c := New()
+	if err := c.Parse(func(c *Conf) error { return c.ParseURLSource(s) }); err != nil {
+		t.Fatal(err)
+	}
+	if c.Int("a") != 1 {
+		t.Fatalf("a = %d, want 1", c.Int("a"))
+	}
+	if format := s.Format(); format != "json" {
+		t.Fatalf("format = %q, want json from the Content-Type", format)
+	}
+
+	// Unchanged
+	changed, err := s.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed {
+		t.Fatal("expected no change on 304")
+	}
+
+	// Changed
+	mu.Lock()
+	body, etag = `{"a": 2}`, `"2"`
+	mu.Unlock()
+	if changed, err = s.Fetch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Fatal("expected a change on 200")
+	}
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Int("a") != 2 {
+		t.Fatalf("a = %d, want 2", c.Int("a"))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if ifNoneMatch[0] != "" || ifNoneMatch[1] != `"1"` || ifNoneMatch[2] != `"1"` {
+		t.Fatalf("If-None-Match headers = %q", ifNoneMatch)
+	}
+}
+
+func TestURLSourceFormat(t *testing.T) {
+	for _, test := range []struct {
+		contentType string
+		path        string
+		want        string
+	}{
+		{"application/json", "/config", "json"},
+		{"application/x-yaml", "/config", "yaml"},
+		{"text/toml", "/config", "toml"},
+		{"text/plain", "/config.yaml", ".yaml"},
+		{"", "/config.json", ".json"},
+	} {
+		s := NewURLSource("http://localhost" + test.path)
+		s.contentType = test.contentType
+		if got := s.Format(); got != test.want {
+			t.Errorf("Format(%q, %q) = %q, want %q", test.contentType, test.path, got, test.want)
+		}
+	}
+	if got := NewURLSource("http://localhost/config.json", WithURLFormat("yaml")).Format(); got != "yaml" {
+		t.Errorf("Format with WithURLFormat = %q, want yaml", got)
+	}
+}
+
+func TestURLSourceAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); ok {
+			if username != "user" || password != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		} else if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write([]byte("a: 1\n"))
+	}))
+	defer server.Close()
+
+	for name, opts := range map[string][]URLOption{
+		"bearer": {WithURLBearerToken("token")},
+		"basic":  {WithURLBasicAuth("user", "pass")},
+	} {
+		c := New()
+		if err := c.ParseURL(server.URL, opts...); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if c.Int("a") != 1 {
+			t.Fatalf("%s: a = %d, want 1", name, c.Int("a"))
+		}
+	}
+	for name, opts := range map[string][]URLOption{
+		"none":      nil,
+		"bad token": {WithURLBearerToken("wrong")},
+		"bad basic": {WithURLBasicAuth("user", "wrong")},
+	} {
+		if err := New().ParseURL(server.URL, opts...); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package conf
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidateTag is the struct tag used to specify validation rules.
+const ValidateTag = "validate"
+
+// ValidationError is a single validation failure for a config key.
+type ValidationError struct {
+	Key     string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Key == "" {
+		return e.Message
+	}
+	return e.Key + ": " + e.Message
+}
+
+// ValidationErrors is every validation failure found while validating a struct.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validate checks the struct in v using validate tags and returns ValidationErrors
+// describing every failure. Keys are named using tag (usually "conf") and are prefixed
+// with path. Rules are comma separated and include:
+//
+//   - required: the value must not be the zero value
+//   - min=N, max=N: the minimum/maximum value for numbers and durations or length of strings, slices and maps
+//   - oneof=a b c: the value must be one of the space separated values
+//   - regex=expr: the string must match the regular expression (it may not contain a comma)
+//   - url: the string must be an absolute URL
+//   - hostport: the string must be a host:port pair with a valid port
+//   - file-exists: the string must be the path to an existing file
+//
+// Other than required, min and max, rules are not checked for empty values.
+func Validate(v interface{}, path string, tag string) error {
+	return validate(v, path, tag, DefaultDelimiter)
+}
+
+// validate runs the validator using the given delimiter to build keys.
+func validate(v interface{}, path string, tag string, delimiter string) error {
+	vr := &validator{tag: tag, delimiter: delimiter}
+	vr.value(reflect.ValueOf(v), path, "")
+	if len(vr.errs) > 0 {
+		return vr.errs
+	}
+	return nil
+}
+
+// validator walks a value collecting validation errors.
+type validator struct {
+	tag       string
+	delimiter string
+	errs      ValidationErrors
+}
+
+// value checks the rules against v and recurses into any nested values.
+func (vr *validator) value(v reflect.Value, key string, rules string) {
+
+	if rules != "" {
+		var min, max string
+		for _, rule := range strings.Split(rules, ",") {
+			rule = strings.TrimSpace(rule)
+			name, arg, _ := strings.Cut(rule, "=")
+			switch name {
+			case "":
+				continue
+			case "min":
+				min = arg
+				continue
+			case "max":
+				max = arg
+				continue
+			}
+			if msg := validateRule(v, name, arg); msg != "" {
+				vr.errs = append(vr.errs, &ValidationError{Key: key, Message: msg})
+				// There is nothing else to check if a required value is missing.
+				if name == "required" {
+					return
+				}
+			}
+		}
+		if min != "" || max != "" {
+			if msg := validateRange(v, min, max); msg != "" {
+				vr.errs = append(vr.errs, &ValidationError{Key: key, Message: msg})
+			}
+		}
+	}
+
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, squash, skip := fieldKey(field, vr.tag)
+			if skip {
+				continue
+			}
+			fieldKey := key
+			if !squash {
+				fieldKey = vr.join(key, name)
+			}
+			vr.value(v.Field(i), fieldKey, field.Tag.Get(ValidateTag))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			vr.value(v.Index(i), vr.join(key, strconv.Itoa(i)), "")
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			vr.value(iter.Value(), vr.join(key, fmt.Sprint(iter.Key().Interface())), "")
+		}
+	}
+}
+
+// join joins a key prefix and name with the delimiter.
+func (vr *validator) join(prefix string, name string) string {
+	return joinKeyDelimiter(prefix, name, vr.delimiter)
+}
+
+// validateRule checks a single rule and returns a message if it fails.
+func validateRule(v reflect.Value, name string, arg string) string {
+
+	if name == "required" {
+		if !v.IsValid() || v.IsZero() {
+			return "is required"
+		}
+		return ""
+	}
+
+	// Everything else checks the underlying value.
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	// Remaining rules only apply to non-empty strings.
+	if v.Kind() != reflect.String {
+		return fmt.Sprintf("rule %s only applies to strings", name)
+	}
+	s := v.String()
+	if s == "" {
+		return ""
+	}
+
+	switch name {
+	case "oneof":
+		options := strings.Fields(arg)
+		for _, option := range options {
+			if s == option {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of %s", strings.Join(options, ", "))
+	case "regex":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return fmt.Sprintf("invalid regex %q: %v", arg, err)
+		}
+		if !re.MatchString(s) {
+			return fmt.Sprintf("must match %s", arg)
+		}
+	case "url":
+		if u, err := url.Parse(s); err != nil || u.Scheme == "" || (u.Host == "" && u.Opaque == "") {
+			return "must be a valid url"
+		}
+	case "hostport":
+		_, port, err := net.SplitHostPort(s)
+		if err != nil {
+			return "must be a valid host:port"
+		}
+		if p, err := strconv.ParseUint(port, 10, 16); err != nil || p == 0 {
+			return "must have a port between 1 and 65535"
+		}
+	case "file-exists":
+		info, err := os.Stat(s)
+		if err != nil {
+			return fmt.Sprintf("file %s does not exist", s)
+		}
+		if info.IsDir() {
+			return fmt.Sprintf("%s is a directory", s)
+		}
+	default:
+		return fmt.Sprintf("unknown validation rule %s", name)
+	}
+	return ""
+}
+
+// validateRange checks the min and max rules. Either may be empty.
+func validateRange(v reflect.Value, min string, max string) string {
+
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	var (
+		value float64
+		parse = func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+		what  = "be"
+	)
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = float64(v.Int())
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			parse = func(s string) (float64, error) {
+				d, err := time.ParseDuration(s)
+				return float64(d), err
+			}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		value = v.Float()
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		value = float64(v.Len())
+		what = "have length"
+	default:
+		return fmt.Sprintf("min/max does not apply to %s", v.Type())
+	}
+
+	var belowMin, aboveMax bool
+	if min != "" {
+		limit, err := parse(min)
+		if err != nil {
+			return fmt.Sprintf("invalid min value %q", min)
+		}
+		belowMin = value < limit
+	}
+	if max != "" {
+		limit, err := parse(max)
+		if err != nil {
+			return fmt.Sprintf("invalid max value %q", max)
+		}
+		aboveMax = value > limit
+	}
+
+	switch {
+	case !belowMin && !aboveMax:
+		return ""
+	case min != "" && max != "":
+		return fmt.Sprintf("must %s between %s and %s", what, min, max)
+	case belowMin:
+		return fmt.Sprintf("must %s at least %s", what, min)
+	default:
+		return fmt.Sprintf("must %s at most %s", what, max)
+	}
+}
+
+// fieldKey returns the config key for a struct field based on the tag.
+// It also returns if the field is squashed into its parent or should be skipped.
+func fieldKey(field reflect.StructField, tag string) (name string, squash bool, skip bool) {
+	name, opts, _ := strings.Cut(field.Tag.Get(tag), ",")
+	if name == "-" {
+		return "", false, true
+	}
+	for _, opt := range strings.Split(opts, ",") {
+		switch opt {
+		case "squash":
+			squash = true
+		case "remain":
+			skip = true
+		}
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name, squash, skip
+}
@@ -0,0 +1,297 @@
+package conf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/knadh/koanf"
+)
+
+// WatchDebounce is how long Watch waits for file events to settle before reloading.
+var WatchDebounce = 100 * time.Millisecond
+
+// SubscriberFunc is called when the value at a subscribed key changes during a Reload.
+// oldValue or newValue will be nil if the key was added or removed.
+type SubscriberFunc func(key string, oldValue, newValue interface{})
+
+// Subscribe registers f to be called when the value at key changes after a Reload.
+// Changes to any key below key also trigger f. Use an empty key to be notified of
+// any change to the configuration. f may call Reload.
+func (c *Conf) Subscribe(key string, f SubscriberFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subscribers[key] = append(c.subscribers[key], f)
+}
+
+// Current returns the current underlying koanf instance. It must not be modified. Reload
+// swaps the embedded koanf instance so use Current rather than the Koanf field. Conf's own
+// methods are safe to use concurrently with Watch.
+func (c *Conf) Current() *koanf.Koanf {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Koanf
+}
+
+// Reload re-runs every ParserFunc passed to Parse into a new koanf instance and
+// swaps it in once all of them succeed. Configuration loaded by calling functions such
+// as ParseFile directly is not reloaded. Tenant layers set directly, for example with
+// SetTenantLayer, are kept as is. If any parser fails the current configuration is
+// left untouched. Subscribers are notified of any changed keys once the reload is done
+// so they may call Reload themselves.
+func (c *Conf) Reload() error {
+	notify, err := c.reload()
+	if err != nil {
+		return err
+	}
+	notify()
+	return nil
+}
+
+// reload rebuilds and swaps in the configuration. It returns a func that notifies the
+// subscribers of any changed keys which must be called without holding reloadMu.
+func (c *Conf) reload() (func(), error) {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+
+	c.mu.RLock()
+	parsers := c.parsers
+	c.mu.RUnlock()
+	if len(parsers) == 0 {
+		return nil, fmt.Errorf("could not reload config: nothing was loaded with Parse")
+	}
+
+	// Build the configuration from scratch.
+	nc := c.sibling()
+	if err := nc.Parse(parsers...); err != nil {
+		return nil, fmt.Errorf("could not reload config: %w", err)
+	}
+
+	// Swap it in.
+	c.mu.Lock()
+	old := c.Koanf
+	c.Koanf = nc.Koanf
+	c.files = nc.files
+	c.tenantFiles, c.tenantDirs = nc.tenantFiles, nc.tenantDirs
+	c.urls = nc.urls
+	for key := range c.marked {
+		nc.secrets[key] = struct{}{}
+	}
+	c.secrets = nc.secrets
+	c.resolved = nc.resolved
+	c.provenance = nc.provenance
+	c.loadOrder, c.loads = nc.loadOrder, nc.loads
+	c.aliased = nc.aliased
+	c.tenantMu.Lock()
+	for tenant, layer := range c.tenants {
+		if layer.keep {
+			nc.tenants[tenant] = layer
+		}
+	}
+	c.tenants = nc.tenants
+	c.tenantMu.Unlock()
+	subscribers := make(map[string][]SubscriberFunc, len(c.subscribers))
+	for key, funcs := range c.subscribers {
+		subscribers[key] = append([]SubscriberFunc(nil), funcs...)
+	}
+	c.mu.Unlock()
+	c.resetTenantCache()
+
+	// Notify anyone who cares.
+	return func() {
+		for key, funcs := range subscribers {
+			oldValue, newValue := old.Get(key), nc.Koanf.Get(key)
+			if reflect.DeepEqual(oldValue, newValue) {
+				continue
+			}
+			for _, f := range funcs {
+				f(key, oldValue, newValue)
+			}
+		}
+	}, nil
+}
+
+// Watch watches every file loaded by Parse, such as with WithFile, and calls Reload when one of them
+// changes. Tenant files and directories loaded by Parse are also watched but only the changed
+// tenant's layer is reloaded with ParseTenantFile, or removed if its file is removed. It returns
+// once the watcher is running and stops watching when ctx is done. Any errors while watching or
+// reloading are passed to errFunc if it is not nil.
+func (c *Conf) Watch(ctx context.Context, errFunc func(error)) error {
+
+	if errFunc == nil {
+		errFunc = func(error) {}
+	}
+
+	c.mu.RLock()
+	files := append([]string(nil), c.files...)
+	tenantFiles := make(map[string]string, len(c.tenantFiles))
+	for file, tenant := range c.tenantFiles {
+		tenantFiles[file] = tenant
+	}
+	tenantDirs := append([]string(nil), c.tenantDirs...)
+	c.mu.RUnlock()
+	if len(files) == 0 && len(tenantFiles) == 0 && len(tenantDirs) == 0 {
+		return fmt.Errorf("no config files to watch")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("could not create watcher: %w", err)
+	}
+
+	// Track the resolved path of each file so that symlink swaps (like Kubernetes
+	// ConfigMaps) are detected. The parent directories are watched as editors and
+	// symlink swaps often replace the file rather than write to it.
+	realPaths := make(map[string]string)
+	tenantPaths := make(map[string]string)
+	watchDirs := append([]string(nil), tenantDirs...)
+	for _, file := range files {
+		realPaths[file] = resolvePath(file)
+		watchDirs = append(watchDirs, filepath.Dir(file))
+	}
+	for file := range tenantFiles {
+		tenantPaths[file] = resolvePath(file)
+		watchDirs = append(watchDirs, filepath.Dir(file))
+	}
+	for _, dir := range watchDirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return fmt.Errorf("could not watch %s: %w", dir, err)
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var (
+			debounce <-chan time.Time
+			reload   bool
+			pending  = make(map[string]struct{}) // Changed tenant files
+		)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+					continue
+				}
+				eventPath := filepath.Clean(event.Name)
+				for file, realPath := range realPaths {
+					currentPath := resolvePath(file)
+					if eventPath == file || eventPath == realPath || currentPath != realPath {
+						realPaths[file] = currentPath
+						reload = true
+						debounce = time.After(WatchDebounce)
+					}
+				}
+				// New files in a tenant directory are new tenants.
+				if _, ok := tenantPaths[eventPath]; !ok && slices.Contains(tenantDirs, filepath.Dir(eventPath)) {
+					if _, err := formatParser(filepath.Ext(eventPath)); err == nil {
+						tenantFiles[eventPath] = strings.TrimSuffix(filepath.Base(eventPath), filepath.Ext(eventPath))
+						tenantPaths[eventPath] = ""
+					}
+				}
+				for file, realPath := range tenantPaths {
+					currentPath := resolvePath(file)
+					if eventPath == file || eventPath == realPath || currentPath != realPath {
+						tenantPaths[file] = currentPath
+						pending[file] = struct{}{}
+						debounce = time.After(WatchDebounce)
+					}
+				}
+
+			case <-debounce:
+				debounce = nil
+				if reload {
+					// Reload rebuilds the tenant layers as well.
+					reload, pending = false, make(map[string]struct{})
+					if err := c.Reload(); err != nil {
+						errFunc(err)
+					}
+					continue
+				}
+				for file := range pending {
+					delete(pending, file)
+					if _, err := os.Stat(file); os.IsNotExist(err) {
+						c.RemoveTenant(tenantFiles[file])
+						continue
+					}
+					if err := c.parseTenantFile(tenantFiles[file], file, false); err != nil {
+						errFunc(err)
+					}
+				}
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				errFunc(fmt.Errorf("config watcher error: %w", err))
+			}
+		}
+	}()
+
+	return nil
+}
+
+// isParsing returns true if Parse is running.
+func (c *Conf) isParsing() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.parsing > 0
+}
+
+// addFile records a config file that has been loaded by Parse so it can be watched.
+func (c *Conf) addFile(file string) {
+	file = filepath.Clean(file)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.parsing == 0 {
+		return
+	}
+	for _, f := range c.files {
+		if f == file {
+			return
+		}
+	}
+	c.files = append(c.files, file)
+}
+
+// addTenantFile records a tenant file that has been loaded by Parse so it can be watched.
+func (c *Conf) addTenantFile(tenant string, file string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.parsing == 0 {
+		return
+	}
+	c.tenantFiles[filepath.Clean(file)] = tenant
+}
+
+// addTenantDir records a tenant directory that has been loaded by Parse so it can be watched.
+func (c *Conf) addTenantDir(dir string) {
+	dir = filepath.Clean(dir)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.parsing == 0 || slices.Contains(c.tenantDirs, dir) {
+		return
+	}
+	c.tenantDirs = append(c.tenantDirs, dir)
+}
+
+// resolvePath returns the path with any symlinks resolved or the original path if it can't be.
+func resolvePath(path string) string {
+	if realPath, err := filepath.EvalSymlinks(path); err == nil {
+		return filepath.Clean(realPath)
+	}
+	return filepath.Clean(path)
+}
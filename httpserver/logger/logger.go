@@ -9,13 +9,15 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/snowzach/golib/log"
 )
 
 type Config struct {
-	Level        slog.Level `conf:"level"`
-	RequestBody  bool       `conf:"request_body"`
-	ResponseBody bool       `conf:"response_body"`
-	IgnorePaths  []string   `conf:"ignore_paths"`
+	Level        slog.Level `conf:"level" help:"Level to log requests at"`
+	RequestBody  bool       `conf:"request_body" help:"Log request bodies"`
+	ResponseBody bool       `conf:"response_body" help:"Log response bodies"`
+	IgnorePaths  []string   `conf:"ignore_paths" help:"Path prefixes to not log"`
 }
 
 func LoggerStandardMiddleware(logger *slog.Logger, config Config) func(http.Handler) http.Handler {
@@ -79,7 +81,20 @@ func LoggerStandardMiddleware(logger *slog.Logger, config Config) func(http.Hand
 			}
 
 			// Write the log entry assuming we're logging at that level.
-			logger.LogAttrs(r.Context(), config.Level, "HTTP Request", fields...)
+			// The request id is already in fields, so don't add it again if the logger adds
+			// context attributes and LoggerContextMiddleware was used.
+			logger.LogAttrs(log.WithoutContext(r.Context(), "request-id"), config.Level, "HTTP Request", fields...)
 		})
 	}
 }
+
+// LoggerContextMiddleware adds the request id set by middleware.RequestID to the request
+// context with log.WithContext so it's included in any records logged with the context.
+func LoggerContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if reqID := middleware.GetReqID(r.Context()); reqID != "" {
+			r = r.WithContext(log.WithContext(r.Context(), "request-id", reqID))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/snowzach/golib/log"
+)
+
+func TestLoggerRequestID(t *testing.T) {
+	for name, test := range map[string]struct {
+		wrap func(slog.Handler) slog.Handler
+		ids  int // Request ids in records logged by the handler
+	}{
+		"plain":   {wrap: func(h slog.Handler) slog.Handler { return h }, ids: 0},
+		"context": {wrap: func(h slog.Handler) slog.Handler { return log.NewContextHandler(h) }, ids: 1},
+	} {
+		var buf bytes.Buffer
+		logger := slog.New(test.wrap(slog.NewJSONHandler(&buf, nil)))
+
+		router := chi.NewRouter()
+		router.Use(middleware.RequestID, LoggerContextMiddleware, LoggerStandardMiddleware(logger, Config{Level: slog.LevelInfo}))
+		router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			logger.InfoContext(r.Context(), "handler")
+		})
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("%s: got %d records, want 2", name, len(lines))
+		}
+		if n := strings.Count(lines[1], `"request-id"`); n != 1 {
+			t.Errorf("%s: access log has %d request ids, want 1: %s", name, n, lines[1])
+		}
+		if n := strings.Count(lines[0], `"request-id"`); n != test.ids {
+			t.Errorf("%s: handler log has %d request ids, want %d: %s", name, n, test.ids, lines[0])
+		}
+	}
+}
@@ -31,7 +31,7 @@ var (
 )
 
 type Config struct {
-	IgnorePaths []string `conf:"ignore_paths"`
+	IgnorePaths []string `conf:"ignore_paths" help:"Path prefixes to not record metrics for"`
 }
 
 func MetricsMiddleware(config Config) func(http.Handler) http.Handler {
@@ -12,12 +12,12 @@ import (
 )
 
 type Config struct {
-	Host     string `conf:"host"`
-	Port     string `conf:"port" default:"8080"`
-	TLS      bool   `conf:"tls"`
-	DevCert  bool   `conf:"devcert"`
-	CertFile string `conf:"certfile"`
-	KeyFile  string `conf:"keyfile"`
+	Host     string `conf:"host" help:"Listen address"`
+	Port     string `conf:"port" default:"8080" help:"Listen port"`
+	TLS      bool   `conf:"tls" help:"Enable TLS"`
+	DevCert  bool   `conf:"devcert" help:"Use a generated development certificate (never use in production)"`
+	CertFile string `conf:"certfile" help:"Path to the TLS certificate"`
+	KeyFile  string `conf:"keyfile" help:"Path to the TLS key"`
 	Handler  http.Handler
 }
 
@@ -0,0 +1,94 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+)
+
+type contextKey struct{}
+
+// WithContext returns a copy of ctx carrying args as attributes added to any record logged
+// with the context. The args are key value pairs or slog.Attrs as with slog.Logger.With and
+// are added to any already in ctx. See ContextHandler.
+func WithContext(ctx context.Context, args ...interface{}) context.Context {
+	var r slog.Record
+	r.Add(args...)
+	if r.NumAttrs() == 0 {
+		return ctx
+	}
+	parent := ContextAttrs(ctx)
+	attrs := make([]slog.Attr, len(parent), len(parent)+r.NumAttrs())
+	copy(attrs, parent)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return context.WithValue(ctx, contextKey{}, attrs)
+}
+
+// WithoutContext returns a copy of ctx without the attributes with the given keys.
+func WithoutContext(ctx context.Context, keys ...string) context.Context {
+	parent := ContextAttrs(ctx)
+	attrs := make([]slog.Attr, 0, len(parent))
+	for _, a := range parent {
+		if !slices.Contains(keys, a.Key) {
+			attrs = append(attrs, a)
+		}
+	}
+	if len(attrs) == len(parent) {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, attrs)
+}
+
+// ContextAttrs returns the attributes added to ctx with WithContext.
+func ContextAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	return attrs
+}
+
+// FromContext returns Logger with the attributes in ctx for code that logs without a
+// context. Don't also pass ctx to its Context methods or the attributes are repeated.
+func FromContext(ctx context.Context) *slog.Logger {
+	attrs := ContextAttrs(ctx)
+	if len(attrs) == 0 {
+		return Logger
+	}
+	return slog.New(Logger.Handler().WithAttrs(attrs))
+}
+
+// ContextHandler is a slog.Handler that adds the attributes in the context added with
+// WithContext to every record before passing it to the wrapped handler. InitLogger wraps
+// every handler with it so the Context funcs and slog's Context methods include them.
+type ContextHandler struct {
+	handler slog.Handler
+}
+
+// NewContextHandler returns a ContextHandler wrapping handler.
+func NewContextHandler(handler slog.Handler) *ContextHandler {
+	return &ContextHandler{handler: handler}
+}
+
+func (h *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs := ContextAttrs(ctx); len(attrs) > 0 {
+		r = r.Clone()
+		r.AddAttrs(attrs...)
+	}
+	return h.handler.Handle(ctx, r)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{handler: h.handler.WithGroup(name)}
+}
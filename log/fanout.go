@@ -0,0 +1,57 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// FanoutHandler is a slog.Handler that passes every record to each of its handlers
+// that is enabled for the record's level.
+type FanoutHandler struct {
+	handlers []slog.Handler
+}
+
+// NewFanoutHandler returns a FanoutHandler writing to handlers.
+func NewFanoutHandler(handlers ...slog.Handler) *FanoutHandler {
+	return &FanoutHandler{handlers: handlers}
+}
+
+func (h *FanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle passes r to every enabled handler and returns any errors joined together.
+func (h *FanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h.handlers {
+		if !handler.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := handler.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *FanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return &FanoutHandler{handlers: handlers}
+}
+
+func (h *FanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return &FanoutHandler{handlers: handlers}
+}
@@ -17,6 +17,10 @@ func Debugf(template string, args ...interface{}) {
 	LogSkip(context.Background(), Logger, slog.LevelDebug, 3, fmt.Sprintf(template, args...))
 }
 
+func DebugContext(ctx context.Context, msg string, args ...interface{}) {
+	LogSkip(ctx, Logger, slog.LevelDebug, 3, msg, args...)
+}
+
 func Info(msg string, args ...interface{}) {
 	LogSkip(context.Background(), Logger, slog.LevelInfo, 3, msg, args...)
 }
@@ -25,6 +29,10 @@ func Infof(template string, args ...interface{}) {
 	LogSkip(context.Background(), Logger, slog.LevelInfo, 3, fmt.Sprintf(template, args...))
 }
 
+func InfoContext(ctx context.Context, msg string, args ...interface{}) {
+	LogSkip(ctx, Logger, slog.LevelInfo, 3, msg, args...)
+}
+
 func Warn(msg string, args ...interface{}) {
 	LogSkip(context.Background(), Logger, slog.LevelWarn, 3, msg, args...)
 }
@@ -33,6 +41,10 @@ func Warnf(template string, args ...interface{}) {
 	LogSkip(context.Background(), Logger, slog.LevelWarn, 3, fmt.Sprintf(template, args...))
 }
 
+func WarnContext(ctx context.Context, msg string, args ...interface{}) {
+	LogSkip(ctx, Logger, slog.LevelWarn, 3, msg, args...)
+}
+
 func Error(msg string, args ...interface{}) {
 	LogSkip(context.Background(), Logger, slog.LevelError, 3, msg, args...)
 }
@@ -41,6 +53,10 @@ func Errorf(template string, args ...interface{}) {
 	LogSkip(context.Background(), Logger, slog.LevelError, 3, fmt.Sprintf(template, args...))
 }
 
+func ErrorContext(ctx context.Context, msg string, args ...interface{}) {
+	LogSkip(ctx, Logger, slog.LevelError, 3, msg, args...)
+}
+
 func Fatal(msg string, args ...interface{}) {
 	LogSkip(context.Background(), Logger, slog.LevelError, 3, msg, args...)
 	os.Exit(1)
@@ -77,5 +93,5 @@ func LogSkip(ctx context.Context, logger *slog.Logger, level slog.Level, skip in
 	runtime.Callers(skip, pcs[:]) // skip [Callers, Infof]
 	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
 	r.Add(args...)
-	_ = logger.Handler().Handle(context.Background(), r)
+	_ = logger.Handler().Handle(ctx, r)
 }
@@ -0,0 +1,211 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// ComponentKey is the attribute added to component loggers with the component name.
+const ComponentKey = "component"
+
+// minLevel lets everything through the base handler so levels are decided by LevelVars.
+const minLevel = slog.Level(math.MinInt)
+
+var (
+	// RootLevel is the level of Logger and of any component without its own level.
+	RootLevel = new(slog.LevelVar)
+
+	// baseHandler is the handler every logger writes to after level filtering. InitLogger
+	// replaces it and existing loggers follow.
+	baseHandler atomic.Pointer[outputHandler]
+
+	componentsMu sync.Mutex
+	components   = make(map[string]*component)
+)
+
+func init() {
+	setBaseHandler(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		AddSource: false,
+		Level:     minLevel,
+	}))
+}
+
+// outputHandler wraps the current base handler so it can be stored atomically.
+type outputHandler struct {
+	handler slog.Handler
+}
+
+// setBaseHandler replaces the handler every logger writes to.
+func setBaseHandler(handler slog.Handler) {
+	baseHandler.Store(&outputHandler{handler: NewContextHandler(handler)})
+}
+
+// component is the level of a named component. It follows RootLevel until it is set.
+type component struct {
+	level   slog.LevelVar
+	inherit atomic.Bool
+}
+
+// Level returns the component's level.
+func (c *component) Level() slog.Level {
+	if c.inherit.Load() {
+		return RootLevel.Level()
+	}
+	return c.level.Level()
+}
+
+// getComponent returns the named component creating it if needed.
+func getComponent(name string) *component {
+	componentsMu.Lock()
+	defer componentsMu.Unlock()
+	c, ok := components[name]
+	if !ok {
+		c = new(component)
+		c.inherit.Store(true)
+		components[name] = c
+	}
+	return c
+}
+
+// Named returns a logger for a component such as "server" or "database" with its own level
+// that can be changed at runtime with SetLevel. Until it is set it uses RootLevel. Records
+// include the component name. It writes to the output configured by the latest InitLogger
+// so it can be called before InitLogger, for example in a package variable.
+func Named(name string) *slog.Logger {
+	return slog.New(&levelHandler{level: getComponent(name)}).With(ComponentKey, name)
+}
+
+// SetLevel sets the level of a component. An empty name sets RootLevel.
+func SetLevel(name string, level slog.Level) {
+	if name == "" {
+		RootLevel.Set(level)
+		return
+	}
+	c := getComponent(name)
+	c.level.Set(level)
+	c.inherit.Store(false)
+}
+
+// ResetLevel makes a component use RootLevel again.
+func ResetLevel(name string) {
+	if name != "" {
+		getComponent(name).inherit.Store(true)
+	}
+}
+
+// GetLevel returns the level of a component. An empty name returns RootLevel.
+func GetLevel(name string) slog.Level {
+	if name == "" {
+		return RootLevel.Level()
+	}
+	return getComponent(name).Level()
+}
+
+// Levels returns the level of every known component.
+func Levels() map[string]slog.Level {
+	componentsMu.Lock()
+	defer componentsMu.Unlock()
+	levels := make(map[string]slog.Level, len(components))
+	for name, c := range components {
+		levels[name] = c.Level()
+	}
+	return levels
+}
+
+// levelsResponse is the body returned by LevelHandler.
+type levelsResponse struct {
+	Root       slog.Level            `json:"root"`
+	Components map[string]slog.Level `json:"components"`
+}
+
+// LevelHandler returns an http.Handler that lists the root and component levels as JSON on
+// GET. On PUT or POST it changes the level of the component query parameter (or the root
+// level if it is empty) to the level query parameter and returns the updated list. A level
+// of "reset" makes the component use the root level again. For example:
+//
+//	curl -X PUT 'http://localhost:8080/debug/levels?component=database&level=debug'
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			name, levelString := r.URL.Query().Get("component"), r.URL.Query().Get("level")
+			if levelString == "reset" {
+				ResetLevel(name)
+				break
+			}
+			level, err := ParseLogLevel(levelString)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			SetLevel(name, level)
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(levelsResponse{Root: RootLevel.Level(), Components: Levels()})
+	})
+}
+
+// levelHandler filters records below level before passing them to the current base
+// handler with any attributes and groups added to the logger.
+type levelHandler struct {
+	level slog.Leveler
+	with  []func(slog.Handler) slog.Handler // WithAttrs and WithGroup calls to apply to the base handler
+	cache atomic.Pointer[cachedHandler]
+}
+
+// cachedHandler is the base handler with the attributes and groups applied.
+type cachedHandler struct {
+	base    *outputHandler
+	handler slog.Handler
+}
+
+// handler returns the base handler with the attributes and groups applied. It's cached
+// until the base handler is replaced.
+func (h *levelHandler) handler() slog.Handler {
+	base := baseHandler.Load()
+	if cached := h.cache.Load(); cached != nil && cached.base == base {
+		return cached.handler
+	}
+	handler := base.handler
+	for _, with := range h.with {
+		handler = with(handler)
+	}
+	h.cache.Store(&cachedHandler{base: base, handler: handler})
+	return handler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.handler().Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler().Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.withFunc(func(handler slog.Handler) slog.Handler {
+		return handler.WithAttrs(attrs)
+	})
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return h.withFunc(func(handler slog.Handler) slog.Handler {
+		return handler.WithGroup(name)
+	})
+}
+
+// withFunc returns a copy of h that also applies with to the base handler.
+func (h *levelHandler) withFunc(with func(slog.Handler) slog.Handler) slog.Handler {
+	return &levelHandler{level: h.level, with: append(h.with[:len(h.with):len(h.with)], with)}
+}
@@ -0,0 +1,97 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// namedLogger is created before InitLogger like a package variable would be.
+var namedLogger = Named("levels-test")
+
+func TestNamedFollowsInitLogger(t *testing.T) {
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.log"), filepath.Join(dir, "second.log")
+
+	if err := InitLogger(&LoggerConfig{Level: "info", Encoding: EncodingJSON, Output: first}); err != nil {
+		t.Fatal(err)
+	}
+	namedLogger.Info("first")
+	if err := InitLogger(&LoggerConfig{Level: "info", Encoding: EncodingJSON, Output: second}); err != nil {
+		t.Fatal(err)
+	}
+	namedLogger.Info("second")
+	t.Cleanup(func() { _ = InitLogger(&LoggerConfig{Level: "info", Encoding: EncodingText, Output: "stderr"}) })
+
+	for file, want := range map[string]string{first: `"msg":"first"`, second: `"msg":"second"`} {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines := strings.Split(strings.TrimSpace(string(b)), "\n"); len(lines) != 1 || !strings.Contains(lines[0], want) || !strings.Contains(lines[0], `"component":"levels-test"`) {
+			t.Errorf("%s = %q, want one record with %s", filepath.Base(file), b, want)
+		}
+	}
+}
+
+func TestComponentLevels(t *testing.T) {
+	logger := Named("levels-test-component")
+	t.Cleanup(func() { ResetLevel("levels-test-component") })
+
+	if logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Fatal("component should inherit the info root level")
+	}
+	SetLevel("levels-test-component", slog.LevelDebug)
+	if !logger.Enabled(context.Background(), slog.LevelDebug) || Logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Fatal("only the component should be at debug")
+	}
+
+	handler := LevelHandler()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/?component=levels-test-component&level=reset", nil))
+	var resp struct {
+		Root       string            `json:"root"`
+		Components map[string]string `json:"components"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Root != "INFO" || resp.Components["levels-test-component"] != "INFO" {
+		t.Fatalf("levels = %+v", resp)
+	}
+	if logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Fatal("component should inherit the root level after reset")
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/?level=bogus", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	for level, want := range map[string]slog.Level{
+		"debug":   slog.LevelDebug,
+		"INFO":    slog.LevelInfo,
+		"Warning": slog.LevelWarn,
+		"err":     slog.LevelError,
+		"info+2":  slog.LevelInfo + 2,
+		"DEBUG-4": slog.LevelDebug - 4,
+	} {
+		if got, err := ParseLogLevel(level); err != nil || got != want {
+			t.Errorf("%s = %v, %v, want %v", level, got, err, want)
+		}
+	}
+	for _, level := range []string{"", "verbose", "info+", "info+x", "+2"} {
+		if _, err := ParseLogLevel(level); err == nil {
+			t.Errorf("%q: expected an error", level)
+		}
+	}
+}
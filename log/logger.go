@@ -8,6 +8,8 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/lmittmann/tint"
@@ -15,14 +17,17 @@ import (
 
 // Defaults
 var (
-	// Sane default logger setup
-	Logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
-		AddSource: false,
-		Level:     slog.LevelInfo,
-	}))
+	// Sane default logger setup at RootLevel (info). It follows InitLogger.
+	Logger = slog.New(&levelHandler{level: RootLevel})
 
 	ErrUnknownLogLevel    = errors.New("unknown log level")
 	ErrUnknownLogEncoding = errors.New("unknown log encoding")
+
+	// The log files opened by InitLogger and how to stop reopening them on SIGHUP
+	// and reporting dropped records.
+	outputMu    sync.Mutex
+	outputFiles []*RotatingFile
+	outputStops []func()
 )
 
 const (
@@ -31,10 +36,42 @@ const (
 )
 
 type LoggerConfig struct {
-	Level    string `conf:"level"`
-	Encoding string `conf:"encoding"`
-	Color    bool   `conf:"color"` // Only valid for console encoding.
-	Output   string `conf:"output"`
+	Level    string `conf:"level" help:"Log level (debug, info, warn, error)"`
+	Encoding string `conf:"encoding" help:"Log encoding (text, console, json)"`
+	Color    bool   `conf:"color" help:"Colorize console output"` // Only valid for console encoding.
+	Output   string `conf:"output" help:"Log output (stderr, stdout or a file path)"`
+
+	// File output options, only valid when Output is a file path.
+	MaxSize     int64         `conf:"max_size" help:"Rotate the log file when it reaches this many megabytes (0 disables)"`
+	MaxAge      time.Duration `conf:"max_age" help:"Rotate the log file after it has been open this long (0 disables)"`
+	MaxBackups  int           `conf:"max_backups" help:"Number of rotated log files to keep (0 keeps all)"`
+	Compress    bool          `conf:"compress" help:"Gzip rotated log files"`
+	ReopenOnHUP bool          `conf:"reopen_on_hup" help:"Reopen the log file on SIGHUP for logrotate"`
+
+	// Levels sets the level of components. See Named.
+	Levels map[string]string `conf:"levels" help:"Log levels of components by name (e.g. database: debug)"`
+
+	// Outputs replaces Encoding, Color, Output and the file options with a list of outputs
+	// that are all written to. Level still applies to every output.
+	Outputs []OutputConfig `conf:"outputs" help:"Log outputs each with their own encoding, level, color and destination"`
+
+	// Sampling limits repeated records. See SamplingHandler.
+	Sampling SamplingConfig `conf:"sampling" help:"Sample repeated log messages to limit identical records"`
+}
+
+// OutputConfig is a single log output. See LoggerConfig.
+type OutputConfig struct {
+	Level    string `conf:"level" help:"Minimum log level for this output (defaults to all)"`
+	Encoding string `conf:"encoding" help:"Log encoding (text, console, json)"`
+	Color    bool   `conf:"color" help:"Colorize console output"` // Only valid for console encoding.
+	Output   string `conf:"output" help:"Log output (stderr, stdout or a file path)"`
+
+	// File output options, only valid when Output is a file path.
+	MaxSize     int64         `conf:"max_size" help:"Rotate the log file when it reaches this many megabytes (0 disables)"`
+	MaxAge      time.Duration `conf:"max_age" help:"Rotate the log file after it has been open this long (0 disables)"`
+	MaxBackups  int           `conf:"max_backups" help:"Number of rotated log files to keep (0 keeps all)"`
+	Compress    bool          `conf:"compress" help:"Gzip rotated log files"`
+	ReopenOnHUP bool          `conf:"reopen_on_hup" help:"Reopen the log file on SIGHUP for logrotate"`
 }
 
 // InitLogger loads a global logger based on a configuration
@@ -44,52 +81,159 @@ func InitLogger(c *LoggerConfig) error {
 	if err != nil {
 		return err
 	}
+	componentLevels := make(map[string]slog.Level, len(c.Levels))
+	for name, l := range c.Levels {
+		if componentLevels[name], err = ParseLogLevel(l); err != nil {
+			return fmt.Errorf("component %s: %w", name, err)
+		}
+	}
+
+	outputs := c.Outputs
+	if len(outputs) == 0 {
+		outputs = []OutputConfig{{
+			Encoding:    c.Encoding,
+			Color:       c.Color,
+			Output:      c.Output,
+			MaxSize:     c.MaxSize,
+			MaxAge:      c.MaxAge,
+			MaxBackups:  c.MaxBackups,
+			Compress:    c.Compress,
+			ReopenOnHUP: c.ReopenOnHUP,
+		}}
+	}
+
+	// Build a handler for every output.
+	var handlers []slog.Handler
+	var files []*RotatingFile
+	var reopen []*RotatingFile
+	for i := range outputs {
+		handler, f, err := newOutputHandler(&outputs[i])
+		if err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+			if len(c.Outputs) > 0 {
+				return fmt.Errorf("log output %d: %w", i, err)
+			}
+			return err
+		}
+		handlers = append(handlers, handler)
+		if f != nil {
+			files = append(files, f)
+			if outputs[i].ReopenOnHUP {
+				reopen = append(reopen, f)
+			}
+		}
+	}
+	handler := handlers[0]
+	if len(handlers) > 1 {
+		handler = NewFanoutHandler(handlers...)
+	}
+	var sampling *SamplingHandler
+	if c.Sampling.Enabled {
+		sampling = NewSamplingHandler(handler, &c.Sampling)
+		handler = sampling
+	}
+
+	RootLevel.Set(level)
+	for name, l := range componentLevels {
+		SetLevel(name, l)
+	}
+
+	// Install the new handler before closing any previous log files and sampling reports
+	// so nothing is logged to them once closed.
+	outputMu.Lock()
+	setBaseHandler(handler)
+	Logger = slog.New(&levelHandler{level: RootLevel})
+	slog.SetDefault(Logger)
+	for _, stop := range outputStops {
+		stop()
+	}
+	for _, f := range outputFiles {
+		f.Close()
+	}
+	outputFiles, outputStops = files, nil
+	for _, f := range reopen {
+		outputStops = append(outputStops, f.ReopenOnSignal(syscall.SIGHUP))
+	}
+	if sampling != nil {
+		outputStops = append(outputStops, sampling.Close)
+	}
+	outputMu.Unlock()
+
+	return nil
+}
+
+// newOutputHandler returns the handler for an output and the log file it opened if any.
+func newOutputHandler(o *OutputConfig) (slog.Handler, *RotatingFile, error) {
+	// Levels are handled by RootLevel and component levels unless the output has its own.
+	var level slog.Leveler = minLevel
+	if o.Level != "" {
+		l, err := ParseLogLevel(o.Level)
+		if err != nil {
+			return nil, nil, err
+		}
+		level = l
+	}
 
 	// Determine the output
 	var w io.Writer
-	switch c.Output {
+	var f *RotatingFile
+	switch o.Output {
 	case "stderr":
 		w = os.Stderr
 	case "stdout":
 		w = os.Stdout
 	default: // Otherwise assume it's a log file path
-		if w, err = os.Open(c.Output); err != nil {
-			return fmt.Errorf("log output file open error: %w", err)
+		f = &RotatingFile{
+			Filename:   o.Output,
+			MaxSize:    o.MaxSize * 1024 * 1024,
+			MaxAge:     o.MaxAge,
+			MaxBackups: o.MaxBackups,
+			Compress:   o.Compress,
+		}
+		// Open it now so any error is returned here.
+		if err := f.Reopen(); err != nil {
+			return nil, nil, fmt.Errorf("log output file open error: %w", err)
 		}
+		w = f
 	}
 
-	var logger *slog.Logger
-	switch c.Encoding {
+	switch o.Encoding {
 	case EncodingText, "console":
-		if c.Color {
-			logger = slog.New(tint.NewHandler(w, &tint.Options{
+		if o.Color {
+			return tint.NewHandler(w, &tint.Options{
 				AddSource:   true,
 				Level:       level,
 				ReplaceAttr: ReplaceAttrTrimSource,
 				TimeFormat:  time.RFC3339Nano,
-			}))
-		} else {
-			logger = slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
-				AddSource:   true,
-				Level:       level,
-				ReplaceAttr: ReplaceAttrTrimSource,
-			}))
+			}), f, nil
 		}
+		return slog.NewTextHandler(w, &slog.HandlerOptions{
+			AddSource:   true,
+			Level:       level,
+			ReplaceAttr: ReplaceAttrTrimSource,
+		}), f, nil
 	case EncodingJSON:
-		logger = slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		return slog.NewJSONHandler(w, &slog.HandlerOptions{
 			AddSource:   true,
 			Level:       level,
 			ReplaceAttr: ReplaceAttrTrimSource,
-		}))
+		}), f, nil
 	}
-	Logger = logger
-	slog.SetDefault(logger)
-	return nil
+	if f != nil {
+		f.Close()
+	}
+	return nil, nil, ErrUnknownLogEncoding
 }
 
 func ReplaceAttrTrimSource(groups []string, a slog.Attr) slog.Attr {
 	if a.Key == slog.SourceKey {
 		if source, ok := a.Value.Any().(*slog.Source); ok {
+			// Records without a caller such as sampling reports have no source.
+			if source.File == "" {
+				return slog.Attr{}
+			}
 			a.Value = slog.StringValue(TrimSource(source.File, 2) + ":" + strconv.Itoa(source.Line))
 		}
 	}
@@ -109,18 +253,29 @@ func TrimSource(source string, parts int) string {
 	return source
 }
 
-// ParseLogLevel is used to parse configuration options into a log level.
+// ParseLogLevel is used to parse configuration options into a log level. Like slog
+// levels, it may have an offset such as info+2 or ERROR-4.
 func ParseLogLevel(level string) (slog.Level, error) {
-	switch strings.ToLower(level) {
+	name, offset := level, 0
+	if i := strings.IndexAny(level, "+-"); i >= 0 {
+		var err error
+		if offset, err = strconv.Atoi(level[i:]); err != nil {
+			return 0, ErrUnknownLogLevel
+		}
+		name = level[:i]
+	}
+	var l slog.Level
+	switch strings.ToLower(name) {
 	case "debug":
-		return slog.LevelDebug, nil
+		l = slog.LevelDebug
 	case "info":
-		return slog.LevelInfo, nil
+		l = slog.LevelInfo
 	case "warn", "warning":
-		return slog.LevelWarn, nil
+		l = slog.LevelWarn
 	case "err", "error":
-		return slog.LevelError, nil
+		l = slog.LevelError
 	default:
 		return 0, ErrUnknownLogLevel
 	}
+	return l + slog.Level(offset), nil
 }
@@ -0,0 +1,258 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BackupTimeFormat is the timestamp added to the name of rotated log files.
+const BackupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotatingFile is an io.WriteCloser that appends to a log file and rotates it once
+// it reaches MaxSize bytes or has been open for MaxAge. Rotated files are renamed with
+// a timestamp, for example app-2006-01-02T15-04-05.000.log, optionally gzipped and
+// only the newest MaxBackups are kept. The file is opened on the first write. Once closed
+// it can't be written to again.
+type RotatingFile struct {
+	// Filename is the log file path.
+	Filename string
+	// MaxSize is the size in bytes that triggers rotation. 0 disables it.
+	MaxSize int64
+	// MaxAge is how long a file is written to before it's rotated. 0 disables it.
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files to keep. 0 keeps them all.
+	MaxBackups int
+	// Compress gzips rotated files.
+	Compress bool
+
+	mu     sync.Mutex
+	millMu sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+	closed bool
+}
+
+// Write writes to the file rotating it first if needed.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	if (f.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.MaxSize) || (f.MaxAge > 0 && time.Since(f.opened) >= f.MaxAge) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file now.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// Reopen closes and reopens the file. Use it after the file has been moved by an external
+// tool like logrotate. See ReopenOnSignal.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	if err := f.close(); err != nil {
+		return err
+	}
+	return f.open()
+}
+
+// ReopenOnSignal reopens the file whenever one of the signals is received until
+// the returned stop function is called.
+func (f *RotatingFile) ReopenOnSignal(sig ...os.Signal) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sig...)
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := f.Reopen(); err != nil {
+					fmt.Fprintf(os.Stderr, "could not reopen log file: %v\n", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// Close closes the file. Any later Write, Rotate or Reopen returns os.ErrClosed.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return f.close()
+}
+
+// open opens the file for appending creating it if needed.
+func (f *RotatingFile) open() error {
+	if dir := filepath.Dir(f.Filename); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("could not create log directory: %w", err)
+		}
+	}
+	file, err := os.OpenFile(f.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("could not stat log file: %w", err)
+	}
+	f.file, f.size, f.opened = file, info.Size(), time.Now()
+	return nil
+}
+
+// close closes the file if it's open.
+func (f *RotatingFile) close() error {
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// rotate renames the current file to a backup, opens a new one and then compresses
+// and removes old backups in the background.
+func (f *RotatingFile) rotate() error {
+	if err := f.close(); err != nil {
+		return err
+	}
+	ext := filepath.Ext(f.Filename)
+	backup := strings.TrimSuffix(f.Filename, ext) + "-" + time.Now().Format(BackupTimeFormat) + ext
+	if err := os.Rename(f.Filename, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not rotate log file: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	go f.mill()
+	return nil
+}
+
+// mill compresses any uncompressed backups and removes backups beyond MaxBackups.
+func (f *RotatingFile) mill() {
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+
+	backups, err := f.backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not list log backups: %v\n", err)
+		return
+	}
+
+	if f.MaxBackups > 0 && len(backups) > f.MaxBackups {
+		for _, backup := range backups[f.MaxBackups:] {
+			if err := os.Remove(backup); err != nil {
+				fmt.Fprintf(os.Stderr, "could not remove log backup: %v\n", err)
+			}
+		}
+		backups = backups[:f.MaxBackups]
+	}
+
+	if f.Compress {
+		for _, backup := range backups {
+			if strings.HasSuffix(backup, ".gz") {
+				continue
+			}
+			if err := gzipFile(backup); err != nil {
+				fmt.Fprintf(os.Stderr, "could not compress log backup: %v\n", err)
+			}
+		}
+	}
+}
+
+// backups returns the rotated files newest first.
+func (f *RotatingFile) backups() ([]string, error) {
+	ext := filepath.Ext(f.Filename)
+	prefix := filepath.Base(strings.TrimSuffix(f.Filename, ext)) + "-"
+	entries, err := os.ReadDir(filepath.Dir(f.Filename))
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		stamp, ok := strings.CutPrefix(name, prefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		stamp = strings.TrimSuffix(strings.TrimSuffix(stamp, ".gz"), ext)
+		if _, err := time.Parse(BackupTimeFormat, stamp); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(filepath.Dir(f.Filename), name))
+	}
+	// The timestamp sorts chronologically.
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups, nil
+}
+
+// gzipFile compresses file to file.gz and removes file.
+func gzipFile(file string) error {
+	in, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(file+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Remove(file)
+}
@@ -0,0 +1,56 @@
+package log
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFileWriteAfterClose(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	f := &RotatingFile{Filename: name}
+	if _, err := f.Write([]byte("one\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("two\n")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Write after Close error = %v, want os.ErrClosed", err)
+	}
+	if err := f.Reopen(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Reopen after Close error = %v, want os.ErrClosed", err)
+	}
+	if f.file != nil {
+		t.Error("file was reopened after Close")
+	}
+	if b, err := os.ReadFile(name); err != nil || string(b) != "one\n" {
+		t.Errorf("file = %q, %v, want %q", b, err, "one\n")
+	}
+}
+
+func TestInitLoggerClosesOldFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := InitLogger(&LoggerConfig{Level: "info", Encoding: EncodingJSON, Output: filepath.Join(dir, "old.log")}); err != nil {
+		t.Fatal(err)
+	}
+	outputMu.Lock()
+	old := outputFiles[0]
+	outputMu.Unlock()
+	oldLogger := Logger
+
+	if err := InitLogger(&LoggerConfig{Level: "info", Encoding: EncodingJSON, Output: filepath.Join(dir, "new.log")}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = InitLogger(&LoggerConfig{Level: "info", Encoding: EncodingText, Output: "stderr"}) })
+
+	// Loggers created before InitLogger follow the new output.
+	oldLogger.Info("after")
+	if old.file != nil {
+		t.Error("old log file was reopened")
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "new.log")); len(b) == 0 {
+		t.Error("record was not written to the new log file")
+	}
+}
@@ -0,0 +1,190 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// SamplingConfig configures a SamplingHandler.
+type SamplingConfig struct {
+	Enabled        bool          `conf:"enabled" help:"Sample repeated log messages"`
+	Initial        int           `conf:"initial" default:"100" help:"Records logged per message and level each interval before sampling"`
+	Thereafter     int           `conf:"thereafter" default:"100" help:"After initial log 1 in this many records"`
+	Interval       time.Duration `conf:"interval" default:"1s" help:"Interval the initial count is reset"`
+	ReportInterval time.Duration `conf:"report_interval" default:"1m" help:"How often to log the number of dropped records"`
+}
+
+// Sampling defaults used for zero SamplingConfig values.
+const (
+	DefaultSamplingInitial        = 100
+	DefaultSamplingThereafter     = 100
+	DefaultSamplingInterval       = time.Second
+	DefaultSamplingReportInterval = time.Minute
+)
+
+// DroppedMessage is the message of records reporting how many records were dropped by sampling.
+const DroppedMessage = "log records dropped by sampling"
+
+// SamplingHandler is a slog.Handler that limits identical records. For each message and
+// level it passes the first Initial records in every Interval and then 1 in Thereafter.
+// Every ReportInterval it logs a record with DroppedMessage for each message that had
+// records dropped with the message and the number dropped. Close stops the reports.
+type SamplingHandler struct {
+	handler slog.Handler
+	sampler *sampler
+}
+
+// sampler is the state shared by a SamplingHandler and the handlers derived from it.
+type sampler struct {
+	handler        slog.Handler // The handler reports are written to
+	initial        int
+	thereafter     int
+	interval       time.Duration
+	reportInterval time.Duration
+
+	mu       sync.Mutex
+	counters map[samplingKey]*samplingCounter
+	done     chan struct{}
+	stopped  chan struct{}
+	once     sync.Once
+}
+
+type samplingKey struct {
+	level slog.Level
+	msg   string
+}
+
+type samplingCounter struct {
+	start   time.Time
+	count   int
+	dropped int
+}
+
+// NewSamplingHandler returns a SamplingHandler writing to handler. Zero values use the
+// defaults so an empty SamplingConfig logs the first 100 records per message each second
+// and then 1 in 100. Enabled is ignored.
+func NewSamplingHandler(handler slog.Handler, c *SamplingConfig) *SamplingHandler {
+	s := &sampler{
+		handler:        handler,
+		initial:        c.Initial,
+		thereafter:     c.Thereafter,
+		interval:       c.Interval,
+		reportInterval: c.ReportInterval,
+		counters:       make(map[samplingKey]*samplingCounter),
+		done:           make(chan struct{}),
+		stopped:        make(chan struct{}),
+	}
+	if s.initial <= 0 {
+		s.initial = DefaultSamplingInitial
+	}
+	if s.thereafter <= 0 {
+		s.thereafter = DefaultSamplingThereafter
+	}
+	if s.interval <= 0 {
+		s.interval = DefaultSamplingInterval
+	}
+	if s.reportInterval <= 0 {
+		s.reportInterval = DefaultSamplingReportInterval
+	}
+	go s.run()
+	return &SamplingHandler{handler: handler, sampler: s}
+}
+
+func (h *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle passes r to the handler unless it's dropped by sampling.
+func (h *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.sampler.sample(samplingKey{level: r.Level, msg: r.Message}, r.Time) {
+		return nil
+	}
+	return h.handler.Handle(ctx, r)
+}
+
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &SamplingHandler{handler: h.handler.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	return &SamplingHandler{handler: h.handler.WithGroup(name), sampler: h.sampler}
+}
+
+// Close stops the reports after reporting any records dropped since the last one.
+// Handlers derived with WithAttrs and WithGroup share the reports.
+func (h *SamplingHandler) Close() {
+	h.sampler.once.Do(func() {
+		close(h.sampler.done)
+		<-h.sampler.stopped
+	})
+}
+
+// sample returns true if a record should be passed on.
+func (s *sampler) sample(key samplingKey, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counters[key]
+	if !ok {
+		c = &samplingCounter{start: now}
+		s.counters[key] = c
+	} else if now.Sub(c.start) >= s.interval {
+		c.start, c.count = now, 0
+	}
+	n := c.count
+	c.count++
+	if n < s.initial || (n-s.initial)%s.thereafter == 0 {
+		return true
+	}
+	c.dropped++
+	return false
+}
+
+// run reports dropped records every reportInterval until Close is called.
+func (s *sampler) run() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(s.reportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			s.report(now)
+		case <-s.done:
+			s.report(time.Now())
+			return
+		}
+	}
+}
+
+// report logs the number of records dropped for each message and removes counters
+// that are no longer in use.
+func (s *sampler) report(now time.Time) {
+	type drop struct {
+		key     samplingKey
+		dropped int
+	}
+	var drops []drop
+
+	s.mu.Lock()
+	for key, c := range s.counters {
+		if c.dropped > 0 {
+			drops = append(drops, drop{key: key, dropped: c.dropped})
+			c.dropped = 0
+		} else if now.Sub(c.start) >= s.interval {
+			delete(s.counters, key)
+		}
+	}
+	s.mu.Unlock()
+
+	ctx := context.Background()
+	for _, d := range drops {
+		if !s.handler.Enabled(ctx, d.key.level) {
+			continue
+		}
+		r := slog.NewRecord(now, d.key.level, DroppedMessage, 0)
+		r.AddAttrs(slog.String("message", d.key.msg), slog.Int("dropped", d.dropped))
+		_ = s.handler.Handle(ctx, r)
+	}
+}
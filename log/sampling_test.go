@@ -0,0 +1,97 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sampledRecords logs n records with the same message from several goroutines and
+// returns the records written including the dropped report written on Close.
+func sampledRecords(t *testing.T, c *SamplingConfig, n int) []map[string]interface{} {
+	t.Helper()
+
+	var (
+		mu  sync.Mutex
+		buf bytes.Buffer
+	)
+	handler := NewSamplingHandler(slog.NewJSONHandler(&lockedWriter{mu: &mu, w: &buf}, nil), c)
+	logger := slog.New(handler)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < n/4; i++ {
+				logger.With("g", g).Info("hot")
+			}
+		}(g)
+	}
+	wg.Wait()
+	handler.Close()
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestSamplingHandler(t *testing.T) {
+	records := sampledRecords(t, &SamplingConfig{Initial: 2, Thereafter: 5, Interval: time.Hour}, 24)
+	// 2 initial, then 1 in 5 of the remaining 22 and the report
+	if len(records) != 8 {
+		t.Fatalf("got %d records, want 8", len(records))
+	}
+	report := records[len(records)-1]
+	if report["msg"] != DroppedMessage || report["message"] != "hot" || report["dropped"] != float64(17) {
+		t.Fatalf("report = %v", report)
+	}
+}
+
+func TestSamplingHandlerDefaults(t *testing.T) {
+	records := sampledRecords(t, &SamplingConfig{Enabled: true}, 400)
+	// 100 initial, then 1 in 100 of the remaining 300 and the report
+	if len(records) != 104 {
+		t.Fatalf("got %d records, want 104", len(records))
+	}
+}
+
+func TestSamplingHandlerInterval(t *testing.T) {
+	var buf bytes.Buffer
+	handler := NewSamplingHandler(slog.NewJSONHandler(&buf, nil), &SamplingConfig{Initial: 1, Interval: time.Hour})
+	defer handler.Close()
+
+	// The first passes, then 1 in 100 starting with the second, then the count is reset.
+	now := time.Now()
+	for _, at := range []time.Time{now, now.Add(time.Minute), now.Add(2 * time.Minute), now.Add(2 * time.Hour)} {
+		if err := handler.Handle(context.Background(), slog.NewRecord(at, slog.LevelInfo, "hot", 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 3 {
+		t.Fatalf("got %d records, want 3", n)
+	}
+}
+
+// lockedWriter serializes writes to w.
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  *bytes.Buffer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
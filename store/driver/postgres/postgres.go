@@ -17,22 +17,22 @@ import (
 )
 
 type Config struct {
-	Username            string        `conf:"username" default:"postgres"`
-	Password            string        `conf:"password" default:"password"`
-	Host                string        `conf:"host" default:"postgres"`
-	Port                string        `conf:"port" default:"5432"`
-	Database            string        `conf:"database" default:"postgres"`
-	Schema              string        `conf:"schema" default:"public"`
-	AutoCreate          bool          `conf:"auto_create" default:"false"`
-	SearchPath          string        `conf:"search_path" default:""`
-	SSLMode             string        `conf:"sslmode" default:"disable"`
-	SSLCert             string        `conf:"sslcert" default:""`
-	SSLKey              string        `conf:"sslkey" default:""`
-	SSLRootCert         string        `conf:"sslrootcert" default:""`
-	Retries             int           `conf:"retries" default:"5"`
-	SleepBetweenRetries time.Duration `conf:"sleep_between_retries" default:"7s"`
-	MaxConnections      int           `conf:"max_connections" default:"40"`
-	WipeConfirm         bool          `conf:"wipe_confirm" default:"false"`
+	Username            string        `conf:"username" default:"postgres" help:"Database username"`
+	Password            string        `conf:"password" default:"password" help:"Database password"`
+	Host                string        `conf:"host" default:"postgres" help:"Database hostname"`
+	Port                string        `conf:"port" default:"5432" help:"Database port"`
+	Database            string        `conf:"database" default:"postgres" help:"Database name"`
+	Schema              string        `conf:"schema" default:"public" help:"Database schema"`
+	AutoCreate          bool          `conf:"auto_create" default:"false" help:"Create the database if it does not exist"`
+	SearchPath          string        `conf:"search_path" default:"" help:"Schema search path"`
+	SSLMode             string        `conf:"sslmode" default:"disable" help:"SSL mode (disable, require, verify-ca, verify-full)"`
+	SSLCert             string        `conf:"sslcert" default:"" help:"Path to the client SSL certificate"`
+	SSLKey              string        `conf:"sslkey" default:"" help:"Path to the client SSL key"`
+	SSLRootCert         string        `conf:"sslrootcert" default:"" help:"Path to the SSL root certificate"`
+	Retries             int           `conf:"retries" default:"5" help:"Number of times to retry connecting"`
+	SleepBetweenRetries time.Duration `conf:"sleep_between_retries" default:"7s" help:"Time to wait between connection retries"`
+	MaxConnections      int           `conf:"max_connections" default:"40" help:"Maximum number of open connections"`
+	WipeConfirm         bool          `conf:"wipe_confirm" default:"false" help:"Confirm wiping the database"`
 
 	Logger          Logger
 	QueryLogger     Logger